@@ -0,0 +1,12 @@
+package blobs
+
+import (
+	"context"
+	"io"
+)
+
+// Store - keeps files attached to Telegram messages, so that Github comments can link to them
+type Store interface {
+	// Put - stores file content under name and returns public URL of stored file
+	Put(ctx context.Context, name string, content io.Reader) (string, error)
+}
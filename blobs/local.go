@@ -0,0 +1,102 @@
+package blobs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var extRe = regexp.MustCompile(`^\.[A-Za-z0-9]{1,10}$`)
+
+// LocalStore - stores files in local directory and serves them over HTTP
+// Files are named by hash of their content, so storing the same file twice keeps one copy
+type LocalStore struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalStore - creates store keeping files in dir, baseURL is public URL LocalStore is served at
+func NewLocalStore(dir string, baseURL string) (*LocalStore, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalStore{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+// Put - stores file content, only extension of name is kept
+func (s *LocalStore) Put(ctx context.Context, name string, content io.Reader) (string, error) {
+	tmp, err := ioutil.TempFile(s.dir, ".upload-")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, hash), content)
+	if err != nil {
+		return "", err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return "", err
+	}
+
+	ext := strings.ToLower(path.Ext(name))
+	if !extRe.MatchString(ext) {
+		ext = ""
+	}
+	stored := hex.EncodeToString(hash.Sum(nil))[:32] + ext
+
+	err = os.Rename(tmp.Name(), filepath.Join(s.dir, stored))
+	if err != nil {
+		return "", err
+	}
+
+	return s.baseURL + "/" + url.PathEscape(stored), nil
+}
+
+// ServeHTTP - serves stored files, directory listing is not available
+func (s *LocalStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := path.Base(r.URL.Path)
+	if strings.HasSuffix(r.URL.Path, "/") || strings.HasPrefix(name, ".") {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if !isInlineMedia(mime.TypeByExtension(path.Ext(name))) {
+		// Other files, e.g. HTML, could run scripts on bridge host when opened in browser
+		w.Header().Set("Content-Disposition", "attachment")
+	}
+	http.ServeFile(w, r, filepath.Join(s.dir, name))
+}
+
+func isInlineMedia(contentType string) bool {
+	if strings.HasPrefix(contentType, "image/svg") {
+		return false
+	}
+	for _, prefix := range []string{"image/", "audio/", "video/"} {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,56 +1,73 @@
 package bot
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
-	"os"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/andreyst/tracker-messenger-bridge/blobs"
+	"github.com/andreyst/tracker-messenger-bridge/config"
+	"github.com/andreyst/tracker-messenger-bridge/filters"
+	"github.com/andreyst/tracker-messenger-bridge/markdown"
+	"github.com/andreyst/tracker-messenger-bridge/messenger"
+	"github.com/andreyst/tracker-messenger-bridge/messenger/slack"
+	"github.com/andreyst/tracker-messenger-bridge/messenger/telegram"
+	"github.com/andreyst/tracker-messenger-bridge/permissions"
+	"github.com/andreyst/tracker-messenger-bridge/routing"
 	"github.com/andreyst/tracker-messenger-bridge/storage"
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
-	"github.com/google/go-github/github"
-	"golang.org/x/oauth2"
-)
-
-// TODO: move path to configuration options
-const (
-	path   = "/github"
-	chatID = -277738237
+	"github.com/andreyst/tracker-messenger-bridge/templates"
+	"github.com/andreyst/tracker-messenger-bridge/tracker"
+	"github.com/andreyst/tracker-messenger-bridge/tracker/gitea"
+	trackergithub "github.com/andreyst/tracker-messenger-bridge/tracker/github"
 )
 
 // Bot - Bot for bridging issue tracker and messaging system
-// This implementation bridges Github issues and Telegram
+// This implementation bridges tracker and messenger chosen in config, Github or Gitea and Telegram or Slack
 type Bot struct {
-	TelegramChatID int64
-	UserName       string
+	Config      *config.Config
+	Router      *routing.Router
+	Permissions *permissions.Policy
+	Filters     *filters.Engine
+	Templates   *templates.Set
+	// Renders tracker Markdown, mentions link to profiles on configured tracker
+	Markdown markdown.Renderer
+
+	UserName string
 
 	DB *sql.DB
 
-	TelegramClient *tgbotapi.BotAPI
-	GithubClient   *github.Client
+	Messenger messenger.Messenger
+	Tracker   tracker.Tracker
+
+	// Storage for files attached to messenger replies, nil if attachments are not bridged
+	Blobs blobs.Store
 
-	EventsChan chan interface{}
+	// Emitted events waiting for dispatch, handled one by one
+	events chan emittedEvent
+
+	queueNotifications chan struct{}
+
+	// Context of running bot and background work started with Go, waited for before DB is closed
+	ctx        context.Context
+	background sync.WaitGroup
 
 	Pollers  map[string]Poller
 	Webhooks map[string]Webhook
+	// Other HTTP handlers served along with webhooks, by path prefix
+	HTTPHandlers map[string]http.Handler
 
+	// Run before event handlers in order they were added, filters are always the first
+	Middlewares   []Middleware
 	EventHandlers []EventHandler
 
-	CommentsMap map[int64]int64
-	MessagesMap map[int64]interface{}
-
-	Mutex            sync.Mutex
-	TelegramReplacer *strings.Replacer
+	Mutex sync.Mutex
 }
 
 // Issue - issue description
@@ -78,143 +95,306 @@ type Comment struct {
 	Body        string
 }
 
+// ReviewComment — pull request review comment on a diff line
+type ReviewComment struct {
+	ID         int64
+	URL        string
+	Owner      string
+	Repo       string
+	PullNumber int64
+	PullURL    string
+	Author     string
+	AuthorURL  string
+	Body       string
+}
+
+// LinkedMessage — messenger message linked to Github object
+type LinkedMessage struct {
+	ChatID    int64
+	MessageID int64
+}
+
+// Reply — messenger reply which was posted as a Github comment
+type Reply struct {
+	CommentID   int64
+	IssueOwner  string
+	IssueRepo   string
+	IssueNumber int64
+	// Reply was posted to pull request review comment thread
+	Review bool
+	// Quote and attribution preceding message text in comment body
+	Prefix string
+	// Tracker login of linked account reply was posted as, empty if it was posted with bot token
+	AuthorLogin string
+}
+
 // Poller - Poller handler
+// Start blocks until ctx is cancelled
 type Poller interface {
-	Start(bot *Bot)
+	Start(ctx context.Context, bot *Bot) error
+}
+
+// QueueHandler - handles events which poller saved to queue with Bot.Enqueue,
+// pollers implementing it should use their name as queue item source
+type QueueHandler interface {
+	HandleQueueItem(ctx context.Context, bot *Bot, item storage.QueueItem) error
 }
 
 // Webhook - Webhook handler
+// Returned error means that webhook data should be processed again later, except for UnprocessedError,
+// r.Context() is cancelled on bot shutdown
 type Webhook interface {
-	Handle(bot *Bot, r *http.Request)
+	Handle(bot *Bot, r *http.Request) error
+}
+
+// WebhookRegistrar - webhook which registers itself in remote service before webhooks are served
+type WebhookRegistrar interface {
+	Register(ctx context.Context, bot *Bot) error
+}
+
+// AuthenticatingWebhook - webhook which checks that request was sent by remote service,
+// other requests are refused before they are queued, so that they can not take source IDs of genuine deliveries
+type AuthenticatingWebhook interface {
+	Authenticate(bot *Bot, header http.Header, body []byte) bool
+}
+
+// DeduplicatingWebhook - webhook which can identify repeated deliveries of the same event,
+// returned source ID is used to skip duplicates in queue, empty ID disables deduplication
+type DeduplicatingWebhook interface {
+	SourceID(header http.Header, body []byte) string
+}
+
+// RespondingWebhook - webhook which answers some requests right away instead of queueing them,
+// e.g. URL verification challenges, returns response body and true for such requests
+type RespondingWebhook interface {
+	Respond(bot *Bot, header http.Header, body []byte) ([]byte, bool)
 }
 
 // EventHandler - event handler
+// Returns whether event was handled and handling error, if any.
+// Handled events are not passed to handlers added after this one.
+// Events failed with transient errors are retried, others are put to dead letters.
+// ctx is cancelled on shutdown, handlers should pass it to messenger and tracker calls
 type EventHandler interface {
-	Handle(bot *Bot, event interface{}) bool
+	Handle(ctx context.Context, bot *Bot, env Envelope) (bool, error)
+}
+
+// Middleware - runs for every event before event handlers, e.g. to log, filter or transform events
+// Calls next to pass event, possibly changed, further, or returns without calling it to drop event
+type Middleware interface {
+	Dispatch(ctx context.Context, bot *Bot, env Envelope, next func(context.Context, Envelope))
 }
 
 // NewBot - Creates and initializes new Bot instance
-func NewBot() (*Bot, error) {
-	// TODO: move chatID to configuration options
+func NewBot(cfg *config.Config) (*Bot, error) {
 	b := &Bot{
-		TelegramChatID: chatID,
+		Config: cfg,
 
-		Webhooks: make(map[string]Webhook),
+		Pollers:      make(map[string]Poller),
+		Webhooks:     make(map[string]Webhook),
+		HTTPHandlers: make(map[string]http.Handler),
 
-		EventsChan: make(chan interface{}),
+		events:      make(chan emittedEvent),
+		Middlewares: []Middleware{FilterMiddleware{}},
 
-		CommentsMap: make(map[int64]int64),
-		MessagesMap: make(map[int64]interface{}),
+		queueNotifications: make(chan struct{}, 1),
 	}
 
-	b.DB = storage.NewDB(os.Getenv("DB_PATH"))
-
-	b.TelegramReplacer = strings.NewReplacer(
-		"_", "\\_",
-		"*", "\\*",
-		"[", "\\[",
-		"]", "\\]",
-		"(", "\\(",
-		")", "\\)",
-		"~", "\\~",
-		"`", "\\`",
-		">", "\\>",
-		"#", "\\#",
-		"+", "\\+",
-		"-", "\\-",
-		"=", "\\=",
-		"|", "\\|",
-		"{", "\\{",
-		"}", "\\}",
-		".", "\\.",
-		"!", "\\!",
-	)
-
-	err := b.initTelegramClient()
+	router, err := routing.NewRouter(cfg.Routes, cfg.DefaultChatID())
 	if err != nil {
-		log.Fatalf("Unable to init telegram client: %v\n", err)
 		return nil, err
 	}
+	b.Router = router
 
-	err = b.initTelegramUsername()
+	policy, err := permissions.NewPolicy(cfg.Permissions)
 	if err != nil {
-		log.Fatalf("Unable to init telegram username: %v\n", err)
 		return nil, err
 	}
+	b.Permissions = policy
 
-	err = b.initGithubClient()
+	engine, err := filters.NewEngine(cfg.Filters)
 	if err != nil {
-		log.Fatalf("Unable to init github client: %v\n", err)
 		return nil, err
 	}
+	b.Filters = engine
+
+	b.Markdown = markdown.Renderer{ProfileURL: profileURL(cfg)}
+	set, err := templates.NewSet(cfg.Templates, b.Markdown)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message templates: %w", err)
+	}
+	b.Templates = set
+
+	b.DB = storage.NewDB(b.Config.DBPath)
+
+	err = b.initMessenger()
+	if err != nil {
+		return nil, fmt.Errorf("unable to init messenger: %w", err)
+	}
+
+	err = b.initTracker()
+	if err != nil {
+		return nil, fmt.Errorf("unable to init tracker: %w", err)
+	}
 
 	return b, nil
 }
 
-func (b *Bot) initTelegramClient() error {
-	telegramClient, err := tgbotapi.NewBotAPI(os.Getenv("TELEGRAM_TOKEN"))
+func (b *Bot) initMessenger() error {
+	var err error
+	switch b.Config.Messenger {
+	case config.MessengerSlack:
+		b.Messenger, err = slack.New(b.Config.Slack)
+	default:
+		b.Messenger, err = telegram.New(b.Config.Telegram.Token)
+	}
 	if err != nil {
-		log.Fatalf("Unable to create telegram client: %v\n", err)
 		return err
 	}
-	b.TelegramClient = telegramClient
+	b.UserName = b.Messenger.UserName()
 
 	return nil
 }
 
-func (b *Bot) initTelegramUsername() error {
-	me, err := b.TelegramClient.GetMe()
-	if err != nil {
-		log.Fatalf("Unable to call getMe: %v\n", err)
-		return err
+// profileURL - returns prefix of user profile URLs on tracker configured in cfg
+func profileURL(cfg *config.Config) string {
+	if cfg.Tracker == config.TrackerGitea {
+		// Gitea profiles are at instance root, as on Github
+		return strings.TrimSuffix(cfg.Gitea.URL, "/") + "/"
 	}
-	b.UserName = me.UserName
 
-	return nil
+	return markdown.GithubProfileURL
 }
 
-func (b *Bot) initGithubClient() error {
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: os.Getenv("GITHUB_TOKEN")},
-	)
-	tc := oauth2.NewClient(ctx, ts)
-	b.GithubClient = github.NewClient(tc)
+func (b *Bot) initTracker() error {
+	switch {
+	case b.Config.Tracker == config.TrackerGitea:
+		b.Tracker = gitea.New(b.Config.Gitea.URL, b.Config.Gitea.Token)
+	case b.Config.Github.AppID != 0:
+		key, err := ioutil.ReadFile(b.Config.Github.AppPrivateKeyPath)
+		if err != nil {
+			return fmt.Errorf("unable to read github app private key: %w", err)
+		}
+		app, err := trackergithub.NewApp(b.Config.Github.AppID, key)
+		if err != nil {
+			return fmt.Errorf("invalid github app private key: %w", err)
+		}
+		app.SetDefaultInstallation(b.Config.Github.AppInstallationID)
+		b.Tracker = trackergithub.NewAppClient(app)
+	default:
+		b.Tracker = trackergithub.New(b.Config.Github.Token)
+	}
 
 	return nil
 }
 
 // Start - start processing updates
-func (b *Bot) Start() {
+// Blocks until ctx is cancelled or one of components fails, then stops
+// all components, closes DB and returns the first component error
+func (b *Bot) Start(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	b.ctx = ctx
+
+	errs := make(chan error, len(b.Pollers)+3)
+	var wg sync.WaitGroup
+	run := func(name string, component func(ctx context.Context) error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := component(ctx); err != nil {
+				errs <- fmt.Errorf("%s: %v", name, err)
+			}
+		}()
+	}
 
-	b.startPollers()
-	b.startWebhooks()
+	for name, poller := range b.Pollers {
+		poller := poller
+		run("poller "+name, func(ctx context.Context) error {
+			return poller.Start(ctx, b)
+		})
+	}
+	run("webhooks", b.runWebhooks)
+	run("queue", b.processQueue)
+	run("events", b.processEvents)
+
+	var err error
+	select {
+	case <-ctx.Done():
+		log.Printf("Shutting down\n")
+	case err = <-errs:
+		log.Printf("Shutting down after error: %v\n", err)
+		cancel()
+	}
 
-	for event := range b.EventsChan {
-		handled := false
-		for _, updateHandler := range b.EventHandlers {
-			handled = handled || updateHandler.Handle(b, event)
-		}
+	wg.Wait()
+	b.background.Wait()
+
+	if closeErr := b.DB.Close(); closeErr != nil && err == nil {
+		err = closeErr
 	}
 
+	return err
 }
 
-func (b *Bot) startPollers() error {
-	for _, poller := range b.Pollers {
-		go poller.Start(b)
+// Go - runs f in background with context of running bot, which is cancelled on shutdown,
+// Start waits for f to return before closing DB
+func (b *Bot) Go(f func(ctx context.Context)) {
+	ctx := b.ctx
+	if ctx == nil {
+		ctx = context.Background()
 	}
 
-	return nil
+	b.background.Add(1)
+	go func() {
+		defer b.background.Done()
+		f(ctx)
+	}()
+}
+
+// Emit - wraps event received from source to envelope and passes it to event handlers,
+// blocks until handlers are done with it, id is assigned by source and may be empty.
+// Returned error means that event was not handled and should be emitted again later, RetryError tells when,
+// events failed in handlers with other errors or too many times are put to dead letters instead
+func (b *Bot) Emit(ctx context.Context, source string, id string, event interface{}) error {
+	env, err := NewEnvelope(source, id, event)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	select {
+	case b.events <- emittedEvent{env: env, attempt: attemptOf(ctx), done: done}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err = <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-func (b *Bot) startWebhooks() error {
-	newWebhooksChan := make(chan interface{})
+func (b *Bot) processEvents(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e := <-b.events:
+			e.done <- b.dispatch(withAttempt(ctx, e.attempt), e.env)
+		}
+	}
+}
+
+func (b *Bot) runWebhooks(ctx context.Context) error {
+	mux := http.NewServeMux()
 
 	for path := range b.Webhooks {
-		// TODO: refactor to separate webhook server
+		path := path
 		// TODO: allow for responses from handler, timeout requests
-		// TODO: error handle on write errors, return 500
-		http.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
 			body, err := ioutil.ReadAll(r.Body)
 			if err != nil {
 				log.Printf("Unable to read webhook body: %v\n", err)
@@ -228,6 +408,20 @@ func (b *Bot) startWebhooks() error {
 				return
 			}
 
+			if authenticating, ok := b.Webhooks[path].(AuthenticatingWebhook); ok && !authenticating.Authenticate(b, r.Header, body) {
+				log.Printf("Refusing unauthenticated webhook on %s\n", path)
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte("Unauthorized"))
+				return
+			}
+
+			if responding, ok := b.Webhooks[path].(RespondingWebhook); ok {
+				if response, ok := responding.Respond(b, r.Header, body); ok {
+					w.Write(response)
+					return
+				}
+			}
+
 			headers, err := json.Marshal(r.Header)
 			if err != nil {
 				log.Printf("Unable to marshal headers to json: %v\n", err)
@@ -236,90 +430,77 @@ func (b *Bot) startWebhooks() error {
 				return
 			}
 
-			whd := storage.WebhookData{
-				Path:    path,
-				Headers: string(headers),
-				Body:    string(body),
+			item := storage.QueueItem{
+				Source:  webhookSourcePrefix + path,
+				Meta:    string(headers),
+				Payload: string(body),
 			}
-
-			storage.SaveWebhookData(b.DB, whd)
-
-			// Try to notify webhook data handlers, skip if nobody is listening
-			var notification interface{}
-			select {
-			case newWebhooksChan <- notification:
-			default:
-			}
-		})
-	}
-
-	go func() {
-		for {
-			whd := storage.LoadWebhookData(b.DB)
-			if whd == nil {
-				// Wait for new webhook to process them immediately
-				// or timeout in case some stored webhook data became visible
-				// after previous try
-				select {
-				case <-newWebhooksChan:
-				case <-time.After(5 * time.Second):
-				}
-				continue
+			if deduplicating, ok := b.Webhooks[path].(DeduplicatingWebhook); ok {
+				item.SourceID = deduplicating.SourceID(r.Header, body)
 			}
 
-			log.Printf("Received message: %v\n", whd.Body)
-
-			var headers http.Header
-			err := json.Unmarshal([]byte(whd.Headers), &headers)
+			queued, err := b.Enqueue(item)
 			if err != nil {
-				log.Printf("Unable to parse JSON headers for message ID: %v, %s\n", whd.RowID, whd.Headers)
-				continue
+				log.Printf("Unable to save webhook data: %v\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte("Internal error"))
+				return
+			}
+			if !queued {
+				log.Printf("Skipping duplicate webhook %s on %s\n", item.SourceID, path)
 			}
+		})
+	}
 
-			for webhookPath, webhook := range b.Webhooks {
-				if webhookPath == path {
-					body := ioutil.NopCloser(bytes.NewReader([]byte(whd.Body)))
-					r := &http.Request{
-						Method: "POST",
-						Body:   body,
-						Header: headers,
-					}
+	for prefix, handler := range b.HTTPHandlers {
+		mux.Handle(strings.TrimSuffix(prefix, "/")+"/", handler)
+	}
 
-					webhook.Handle(b, r)
-				}
-			}
+	addr := fmt.Sprintf(":%d", b.Config.Port)
+	server := &http.Server{Addr: addr, Handler: mux}
 
-			storage.DeleteWebhookData(b.DB, whd.RowID)
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Listening %s for webhooks", addr)
+		err := server.ListenAndServe()
+		if err == http.ErrServerClosed {
+			err = nil
 		}
+		serverErr <- err
 	}()
 
-	port, err := initPort()
-	if err != nil {
-		log.Fatalf("Unable to init webhook port: %v\n", err)
-		return err
+	// Webhooks are registered after server is started, so that first deliveries are not refused
+	err := b.registerWebhooks(ctx)
+	if err == nil {
+		select {
+		case <-ctx.Done():
+		case err = <-serverErr:
+		}
 	}
 
-	addr := fmt.Sprintf(":%d", port)
-	go http.ListenAndServe(addr, nil)
-	log.Printf("Listening %s for webhooks", addr)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if shutdownErr := server.Shutdown(shutdownCtx); shutdownErr != nil && err == nil {
+		err = shutdownErr
+	}
 
-	return nil
+	return err
 }
 
-func initPort() (int, error) {
-	portStr := os.Getenv("PORT")
-	if portStr == "" {
-		log.Fatalf("Missing PORT env variable")
-		return 0, errors.New("Missing PORT env variable")
-	}
+func (b *Bot) registerWebhooks(ctx context.Context) error {
+	for path, webhook := range b.Webhooks {
+		registrar, ok := webhook.(WebhookRegistrar)
+		if !ok {
+			continue
+		}
 
-	port, err := strconv.Atoi(portStr)
-	if err != nil {
-		log.Fatalf("Incorrect PORT value (expected int): %v\n", err)
-		return 0, err
+		err := registrar.Register(ctx, b)
+		if err != nil {
+			return fmt.Errorf("unable to register webhook %s: %w", path, err)
+		}
 	}
 
-	return port, nil
+	return nil
 }
 
 // AddPoller - add a poller
@@ -332,6 +513,16 @@ func (b *Bot) AddWebhook(path string, webhook Webhook) {
 	b.Webhooks[path] = webhook
 }
 
+// AddHTTPHandler - serve handler for requests with path prefix along with webhooks
+func (b *Bot) AddHTTPHandler(prefix string, handler http.Handler) {
+	b.HTTPHandlers[prefix] = handler
+}
+
+// AddMiddleware - add a middleware, it runs after previously added ones
+func (b *Bot) AddMiddleware(middleware Middleware) {
+	b.Middlewares = append(b.Middlewares, middleware)
+}
+
 // AddEventHandler - add an event handler
 func (b *Bot) AddEventHandler(eventHandler EventHandler) {
 	b.EventHandlers = append(b.EventHandlers, eventHandler)
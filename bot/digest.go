@@ -0,0 +1,37 @@
+package bot
+
+import (
+	"fmt"
+
+	"github.com/andreyst/tracker-messenger-bridge/storage"
+)
+
+// CollectForDigest - saves event of given type for every chat which gets digests of such events,
+// repo of event and labels of its issue are matched against routes
+func (b *Bot) CollectForDigest(event string, labels []string, e storage.DigestEvent) error {
+	for _, target := range b.Router.DigestTargets(e.Repo, event, labels) {
+		e.ChatID = target.ChatID
+		e.Schedule = target.Schedule
+		err := storage.SaveDigestEvent(b.DB, e)
+		if err != nil {
+			return fmt.Errorf("unable to save digest event for chat %d: %w", target.ChatID, err)
+		}
+	}
+
+	return nil
+}
+
+// PendingDigests - returns chats and schedules with events waiting for digest
+func (b *Bot) PendingDigests() ([]storage.PendingDigest, error) {
+	return storage.ListPendingDigests(b.DB)
+}
+
+// DigestEvents - returns events waiting for digest to chat on schedule, saved before given UTC time
+func (b *Bot) DigestEvents(chatID int64, schedule string, before string) ([]storage.DigestEvent, error) {
+	return storage.ListDigestEvents(b.DB, chatID, schedule, before)
+}
+
+// DeleteDigestEvents - deletes events after digest message with them was posted
+func (b *Bot) DeleteDigestEvents(rowIDs []int64) error {
+	return storage.DeleteDigestEvents(b.DB, rowIDs)
+}
@@ -0,0 +1,194 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/andreyst/tracker-messenger-bridge/storage"
+)
+
+func typeName(v interface{}) string {
+	return fmt.Sprintf("%T", v)
+}
+
+// emittedEvent - event passed from Emit to dispatching goroutine, dispatch result is sent to done
+type emittedEvent struct {
+	env Envelope
+	// Attempt to process queue item event comes from
+	attempt int
+	done    chan<- error
+}
+
+// dispatch - passes event through middlewares in order they were added, then to event handlers,
+// returns error if event was not handled and should be dispatched again later
+func (b *Bot) dispatch(ctx context.Context, env Envelope) error {
+	var err error
+	b.next(0, &err)(ctx, env)
+
+	return err
+}
+
+// next - returns function passing event to middleware i, or to event handlers after the last middleware,
+// handlers set err
+func (b *Bot) next(i int, err *error) func(context.Context, Envelope) {
+	if i == len(b.Middlewares) {
+		return func(ctx context.Context, env Envelope) {
+			*err = b.runHandlers(ctx, env)
+		}
+	}
+
+	return func(ctx context.Context, env Envelope) {
+		b.Middlewares[i].Dispatch(ctx, b, env, b.next(i+1, err))
+	}
+}
+
+// runHandlers - runs event handlers in order they were added until one of them handles event,
+// so that e.g. commands are not posted to tracker as replies too
+// Returns UnprocessedError if some handler failed and event was put to dead letters
+func (b *Bot) runHandlers(ctx context.Context, env Envelope) error {
+	var deadLettered error
+	for _, eventHandler := range b.EventHandlers {
+		handled, err := b.handle(ctx, eventHandler, env)
+		var unprocessed *UnprocessedError
+		if errors.As(err, &unprocessed) {
+			deadLettered = err
+		} else if err != nil {
+			return err
+		}
+		if handled {
+			break
+		}
+	}
+
+	return deadLettered
+}
+
+// handle - runs event handler, returns RetryError for transient errors until retry.max_attempts is reached,
+// so that event is retried from queue without blocking other events.
+// Failed events are put to dead letters and UnprocessedError is returned,
+// other errors mean that event was neither handled nor put to dead letters, e.g. on shutdown
+func (b *Bot) handle(ctx context.Context, eventHandler EventHandler, env Envelope) (bool, error) {
+	handled, err := eventHandler.Handle(ctx, b, env)
+	if err == nil {
+		return handled, nil
+	}
+	attempts := attemptOf(ctx)
+	if ctx.Err() != nil {
+		return handled, fmt.Errorf("handler %s interrupted on attempt %d: %w", typeName(eventHandler), attempts, err)
+	}
+
+	if transient, _ := isTransient(err); transient && attempts < b.Config.Retry.MaxAttempts {
+		delay := b.retryDelay(attempts, err)
+		log.Printf("Handler %s failed (attempt %d), retrying in %v: %v\n", typeName(eventHandler), attempts, delay, err)
+
+		return handled, &RetryError{Err: fmt.Errorf("handler %s failed: %w", typeName(eventHandler), err), After: delay}
+	}
+
+	log.Printf("Handler %s failed after %d attempts: %v\n", typeName(eventHandler), attempts, err)
+
+	rowID, saveErr := b.saveDeadLetter(ctx, eventHandler, env, attempts, err)
+	if saveErr != nil {
+		return handled, fmt.Errorf("unable to save dead letter of handler %s: %w", typeName(eventHandler), saveErr)
+	}
+
+	return handled, &UnprocessedError{Err: fmt.Errorf("handler %s failed, saved dead letter %d: %w", typeName(eventHandler), rowID, err)}
+}
+
+// saveDeadLetter - puts event failed in handler to dead letters and returns dead letter row ID,
+// replayed dead letter is updated instead, so that it is not duplicated
+func (b *Bot) saveDeadLetter(ctx context.Context, eventHandler EventHandler, env Envelope, attempts int, err error) (int64, error) {
+	if rowID := deadLetterOf(ctx); rowID != 0 {
+		return rowID, storage.UpdateDeadLetterAttempt(b.DB, rowID, attempts, err.Error())
+	}
+
+	payload, marshalErr := json.Marshal(env)
+	if marshalErr != nil {
+		return 0, fmt.Errorf("unable to marshal %s: %w", env.Kind, marshalErr)
+	}
+
+	dl, saveErr := storage.SaveDeadLetter(b.DB, storage.DeadLetter{
+		Handler:  typeName(eventHandler),
+		Kind:     env.Kind,
+		Payload:  string(payload),
+		Error:    err.Error(),
+		Attempts: attempts,
+	})
+	if saveErr != nil {
+		return 0, saveErr
+	}
+	log.Printf("Saved dead letter ID %d\n", dl.RowID)
+
+	return dl.RowID, nil
+}
+
+// backoff - returns exponential delay before attempt following failed one
+func (b *Bot) backoff(attempt int) time.Duration {
+	delay := b.Config.Retry.InitialBackoff
+	for i := 1; i < attempt && delay < b.Config.Retry.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > b.Config.Retry.MaxBackoff {
+		delay = b.Config.Retry.MaxBackoff
+	}
+
+	return delay
+}
+
+// DeadLetters - lists events which were not processed successfully
+func (b *Bot) DeadLetters() ([]storage.DeadLetter, error) {
+	return storage.ListDeadLetters(b.DB)
+}
+
+// ReplayDeadLetter - runs handler which failed on dead letter event again
+func (b *Bot) ReplayDeadLetter(ctx context.Context, rowID int64) error {
+	dl, err := storage.LoadDeadLetter(b.DB, rowID)
+	if err != nil {
+		return err
+	}
+	if dl == nil {
+		return fmt.Errorf("dead letter %d not found", rowID)
+	}
+	if dl.ReplayedAt != "" {
+		return fmt.Errorf("dead letter %d was already replayed at %s", rowID, dl.ReplayedAt)
+	}
+
+	env, err := decodeEnvelope(dl.Kind, []byte(dl.Payload))
+	if err != nil {
+		return err
+	}
+
+	var eventHandler EventHandler
+	for _, h := range b.EventHandlers {
+		if typeName(h) == dl.Handler {
+			eventHandler = h
+			break
+		}
+	}
+	if eventHandler == nil {
+		return fmt.Errorf("handler %s is not registered", dl.Handler)
+	}
+
+	// Replay runs handler the same way as queue does, retries are made in place, as dead letters are not queued
+	ctx = withDeadLetter(ctx, rowID)
+	for attempt := 1; ; attempt++ {
+		_, err = b.handle(withAttempt(ctx, attempt), eventHandler, env)
+		var retry *RetryError
+		if !errors.As(err, &retry) {
+			if err != nil {
+				// Failed replay has already updated dead letter, interrupted one is left as it was
+				return err
+			}
+			return storage.UpdateDeadLetterAttempt(b.DB, rowID, attempt, "")
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(retry.After):
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// EnvelopeVersion - version of Envelope layout, saved with dead letters,
+// so that envelopes saved by older versions can be told apart when replayed
+const EnvelopeVersion = 1
+
+// Envelope - event passed from webhooks and pollers through middlewares to event handlers
+type Envelope struct {
+	Version int `json:"version"`
+	// System event came from, e.g. "github" or "telegram"
+	Source string `json:"source"`
+	// Kind registered for payload type with RegisterEventKind, e.g. "tracker.issue"
+	Kind string `json:"kind"`
+	// ID assigned by source, e.g. webhook delivery ID or update ID, empty if source has none
+	ID string `json:"id"`
+	// When event was received
+	Time time.Time `json:"time"`
+	// Event of type registered for kind, e.g. tracker.IssueEvent or messenger.Update
+	Payload interface{} `json:"payload"`
+}
+
+var (
+	eventKinds = make(map[string]reflect.Type)
+	typeKinds  = make(map[reflect.Type]string)
+)
+
+// RegisterEventKind - registers kind of events with payload type, events of unregistered types can not be emitted
+// Kinds are saved to dead letters, so that failed events can be replayed later
+// Should be called from init()
+func RegisterEventKind(kind string, payload interface{}) {
+	t := reflect.TypeOf(payload)
+	if other, ok := typeKinds[t]; ok && other != kind {
+		panic(fmt.Sprintf("event type %s is already registered as %s", t, other))
+	}
+
+	eventKinds[kind] = t
+	typeKinds[t] = kind
+}
+
+// NewEnvelope - wraps payload of registered type received from source now
+func NewEnvelope(source string, id string, payload interface{}) (Envelope, error) {
+	kind, ok := typeKinds[reflect.TypeOf(payload)]
+	if !ok {
+		return Envelope{}, fmt.Errorf("event type %s is not registered", typeName(payload))
+	}
+
+	return Envelope{
+		Version: EnvelopeVersion,
+		Source:  source,
+		Kind:    kind,
+		ID:      id,
+		Time:    time.Now(),
+		Payload: payload,
+	}, nil
+}
+
+// decodeEnvelope - decodes envelope saved to dead letter with kind,
+// returns UnprocessedError for kinds which are not registered, e.g. Go type names of bare payloads
+// saved before envelopes were introduced, and for envelopes of other versions
+func decodeEnvelope(kind string, data []byte) (Envelope, error) {
+	t, ok := eventKinds[kind]
+	if !ok {
+		return Envelope{}, Unprocessed("unknown event kind %s", kind)
+	}
+
+	var saved struct {
+		Envelope
+		Payload json.RawMessage `json:"payload"`
+	}
+	err := json.Unmarshal(data, &saved)
+	if err != nil {
+		return Envelope{}, Unprocessed("unable to unmarshal %s envelope: %v", kind, err)
+	}
+	if saved.Version != EnvelopeVersion {
+		// No older layouts are known yet, envelopes of other versions would be decoded wrongly
+		return Envelope{}, Unprocessed("unsupported %s envelope version %d, expected %d", kind, saved.Version, EnvelopeVersion)
+	}
+
+	env := saved.Envelope
+	env.Payload, err = decodePayload(t, saved.Payload)
+	if err != nil {
+		return Envelope{}, &UnprocessedError{Err: err}
+	}
+
+	return env, nil
+}
+
+func decodePayload(t reflect.Type, data []byte) (interface{}, error) {
+	payload := reflect.New(t)
+	err := json.Unmarshal(data, payload.Interface())
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshal %s: %w", t, err)
+	}
+
+	return payload.Elem().Interface(), nil
+}
@@ -0,0 +1,69 @@
+package bot
+
+import (
+	"github.com/andreyst/tracker-messenger-bridge/storage"
+	"github.com/andreyst/tracker-messenger-bridge/tracker"
+)
+
+// TrackerFor - returns tracker client acting as messenger user if user linked tracker account,
+// otherwise returns bot client and nil identity, so that caller can add attribution
+func (b *Bot) TrackerFor(telegramUserID int) (tracker.Tracker, *storage.Identity, error) {
+	identity, err := b.Identity(telegramUserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if identity == nil {
+		return b.Tracker, nil, nil
+	}
+
+	return b.Tracker.As(identity.GithubToken), identity, nil
+}
+
+// ReplyTracker - returns tracker client acting as the account reply was posted as, so that its comment
+// can be edited or deleted, or nil if messenger user unlinked or changed that account since then
+func (b *Bot) ReplyTracker(telegramUserID int, reply Reply) (tracker.Tracker, error) {
+	if reply.AuthorLogin == "" {
+		return b.Tracker, nil
+	}
+
+	identity, err := b.Identity(telegramUserID)
+	if err != nil {
+		return nil, err
+	}
+	if identity == nil || identity.GithubLogin != reply.AuthorLogin {
+		return nil, nil
+	}
+
+	return b.Tracker.As(identity.GithubToken), nil
+}
+
+// LinkIdentity - links messenger user to tracker account, token is encrypted if token_encryption_key is configured
+func (b *Bot) LinkIdentity(identity storage.Identity) error {
+	token, err := sealToken(b.Config.TokenKey(), identity.GithubToken)
+	if err != nil {
+		return err
+	}
+	identity.GithubToken = token
+
+	return storage.SaveIdentity(b.DB, identity)
+}
+
+// UnlinkIdentity - forgets tracker account of messenger user, returns false if user was not linked
+func (b *Bot) UnlinkIdentity(telegramUserID int) (bool, error) {
+	return storage.DeleteIdentity(b.DB, int64(telegramUserID))
+}
+
+// Identity - returns tracker account linked to messenger user with decrypted token, nil if user is not linked
+func (b *Bot) Identity(telegramUserID int) (*storage.Identity, error) {
+	identity, err := storage.FindIdentity(b.DB, int64(telegramUserID))
+	if err != nil || identity == nil {
+		return identity, err
+	}
+
+	identity.GithubToken, err = openToken(b.Config.TokenKey(), identity.GithubToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
@@ -0,0 +1,261 @@
+package bot
+
+import (
+	"github.com/andreyst/tracker-messenger-bridge/storage"
+)
+
+// LinkIssue - remember that messenger message announces an issue
+func (b *Bot) LinkIssue(chatID int64, messageID int64, issue Issue) error {
+	return storage.SaveMessageLink(b.DB, storage.MessageLink{
+		Kind:        storage.LinkKindIssue,
+		Source:      b.Tracker.Name(),
+		ChatID:      chatID,
+		MessageID:   messageID,
+		Owner:       issue.Owner,
+		Repo:        issue.Repo,
+		IssueNumber: issue.Number,
+		IssueURL:    issue.URL,
+		URL:         issue.URL,
+		Author:      issue.Author,
+		AuthorURL:   issue.AuthorURL,
+		Title:       issue.Title,
+		Body:        issue.Description,
+	})
+}
+
+// LinkIssueStatus - remember that messenger message announces issue status change
+func (b *Bot) LinkIssueStatus(chatID int64, messageID int64, issue Issue) error {
+	return storage.SaveMessageLink(b.DB, storage.MessageLink{
+		Kind:        storage.LinkKindIssueStatus,
+		Source:      b.Tracker.Name(),
+		ChatID:      chatID,
+		MessageID:   messageID,
+		Owner:       issue.Owner,
+		Repo:        issue.Repo,
+		IssueNumber: issue.Number,
+		IssueURL:    issue.URL,
+		URL:         issue.URL,
+		Author:      issue.Author,
+		AuthorURL:   issue.AuthorURL,
+		Title:       issue.Title,
+		Body:        issue.Description,
+	})
+}
+
+// LinkComment - remember that messenger message relays a Github comment
+func (b *Bot) LinkComment(chatID int64, messageID int64, comment Comment) error {
+	return storage.SaveMessageLink(b.DB, storage.MessageLink{
+		Kind:        storage.LinkKindComment,
+		Source:      b.Tracker.Name(),
+		ChatID:      chatID,
+		MessageID:   messageID,
+		Owner:       comment.IssueOwner,
+		Repo:        comment.IssueRepo,
+		IssueNumber: comment.IssueNumber,
+		IssueURL:    comment.IssueURL,
+		CommentID:   comment.ID,
+		URL:         comment.URL,
+		Author:      comment.Author,
+		AuthorURL:   comment.AuthorURL,
+		Body:        comment.Body,
+	})
+}
+
+// LinkReviewComment - remember that messenger message relays a pull request review comment
+func (b *Bot) LinkReviewComment(chatID int64, messageID int64, comment ReviewComment) error {
+	return storage.SaveMessageLink(b.DB, storage.MessageLink{
+		Kind:        storage.LinkKindReviewComment,
+		Source:      b.Tracker.Name(),
+		ChatID:      chatID,
+		MessageID:   messageID,
+		Owner:       comment.Owner,
+		Repo:        comment.Repo,
+		IssueNumber: comment.PullNumber,
+		IssueURL:    comment.PullURL,
+		CommentID:   comment.ID,
+		URL:         comment.URL,
+		Author:      comment.Author,
+		AuthorURL:   comment.AuthorURL,
+		Body:        comment.Body,
+	})
+}
+
+// LinkReply - remember that messenger message was posted to Github as a comment
+func (b *Bot) LinkReply(chatID int64, messageID int64, reply Reply) error {
+	kind := storage.LinkKindReply
+	if reply.Review {
+		kind = storage.LinkKindReviewReply
+	}
+
+	return storage.SaveMessageLink(b.DB, storage.MessageLink{
+		Kind:        kind,
+		Source:      b.Tracker.Name(),
+		ChatID:      chatID,
+		MessageID:   messageID,
+		Owner:       reply.IssueOwner,
+		Repo:        reply.IssueRepo,
+		IssueNumber: reply.IssueNumber,
+		CommentID:   reply.CommentID,
+		Author:      reply.AuthorLogin,
+		Body:        reply.Prefix,
+	})
+}
+
+// LinkedObject - returns Issue, Comment, ReviewComment or Reply linked to messenger message,
+// or nil if the message is not linked to anything
+func (b *Bot) LinkedObject(chatID int64, messageID int64) (interface{}, error) {
+	link, err := storage.FindMessageLink(b.DB, chatID, messageID)
+	if err != nil || link == nil {
+		return nil, err
+	}
+
+	return linkToObject(link), nil
+}
+
+// IssueMessages - returns messages announcing an issue or pull request in all chats, oldest first,
+// status change messages are not included
+func (b *Bot) IssueMessages(owner string, repo string, number int64) ([]LinkedMessage, error) {
+	links, err := storage.FindIssueMessageLinks(b.DB, b.Tracker.Name(), storage.LinkKindIssue, owner, repo, number)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]LinkedMessage, 0, len(links))
+	for _, link := range links {
+		messages = append(messages, LinkedMessage{ChatID: link.ChatID, MessageID: link.MessageID})
+	}
+
+	return messages, nil
+}
+
+// IsOwnComment - checks whether issue comment was created by the bot from a messenger reply
+func (b *Bot) IsOwnComment(commentID int64) (bool, error) {
+	return b.isOwn(storage.LinkKindReply, commentID)
+}
+
+// IsOwnReviewComment - checks whether pull request review comment was created by the bot from a messenger reply
+func (b *Bot) IsOwnReviewComment(commentID int64) (bool, error) {
+	return b.isOwn(storage.LinkKindReviewReply, commentID)
+}
+
+// CommentMessages - returns messages relaying a Github issue comment in all chats, oldest first
+func (b *Bot) CommentMessages(commentID int64) ([]LinkedMessage, error) {
+	return b.commentMessages(storage.LinkKindComment, commentID)
+}
+
+// ReviewCommentMessages - returns messages relaying a pull request review comment in all chats, oldest first
+func (b *Bot) ReviewCommentMessages(commentID int64) ([]LinkedMessage, error) {
+	return b.commentMessages(storage.LinkKindReviewComment, commentID)
+}
+
+// UnlinkMessage - forget what messenger message is linked to, e.g. after linked object is deleted
+func (b *Bot) UnlinkMessage(chatID int64, messageID int64) error {
+	return storage.DeleteMessageLink(b.DB, chatID, messageID)
+}
+
+// UnlinkComment - forget all messenger messages linked to issue comment after it is deleted
+func (b *Bot) UnlinkComment(commentID int64) error {
+	return b.unlink(commentID, storage.LinkKindComment, storage.LinkKindReply)
+}
+
+// UnlinkReviewComment - forget all messenger messages linked to pull request review comment after it is deleted
+func (b *Bot) UnlinkReviewComment(commentID int64) error {
+	return b.unlink(commentID, storage.LinkKindReviewComment, storage.LinkKindReviewReply)
+}
+
+func (b *Bot) isOwn(kind string, commentID int64) (bool, error) {
+	link, err := storage.FindMessageLinkByCommentID(b.DB, b.Tracker.Name(), kind, commentID)
+	if err != nil {
+		return false, err
+	}
+
+	return link != nil, nil
+}
+
+// unlink - deletes links of given kinds for a comment, issue and review comment IDs are not unique together
+func (b *Bot) unlink(commentID int64, kinds ...string) error {
+	for _, kind := range kinds {
+		err := storage.DeleteCommentMessageLinks(b.DB, b.Tracker.Name(), kind, commentID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *Bot) commentMessages(kind string, commentID int64) ([]LinkedMessage, error) {
+	links, err := storage.FindCommentMessageLinks(b.DB, b.Tracker.Name(), kind, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]LinkedMessage, 0, len(links))
+	for _, link := range links {
+		messages = append(messages, LinkedMessage{ChatID: link.ChatID, MessageID: link.MessageID})
+	}
+
+	return messages, nil
+}
+
+func linkToObject(link *storage.MessageLink) interface{} {
+	switch link.Kind {
+	case storage.LinkKindIssue, storage.LinkKindIssueStatus:
+		return Issue{
+			Owner:       link.Owner,
+			Repo:        link.Repo,
+			Number:      link.IssueNumber,
+			URL:         link.URL,
+			Author:      link.Author,
+			AuthorURL:   link.AuthorURL,
+			Title:       link.Title,
+			Description: link.Body,
+		}
+	case storage.LinkKindComment:
+		return Comment{
+			ID:          link.CommentID,
+			URL:         link.URL,
+			IssueOwner:  link.Owner,
+			IssueRepo:   link.Repo,
+			IssueNumber: link.IssueNumber,
+			IssueURL:    link.IssueURL,
+			Author:      link.Author,
+			AuthorURL:   link.AuthorURL,
+			Body:        link.Body,
+		}
+	case storage.LinkKindReviewComment:
+		return ReviewComment{
+			ID:         link.CommentID,
+			URL:        link.URL,
+			Owner:      link.Owner,
+			Repo:       link.Repo,
+			PullNumber: link.IssueNumber,
+			PullURL:    link.IssueURL,
+			Author:     link.Author,
+			AuthorURL:  link.AuthorURL,
+			Body:       link.Body,
+		}
+	case storage.LinkKindReply, storage.LinkKindReviewReply:
+		return Reply{
+			CommentID:   link.CommentID,
+			IssueOwner:  link.Owner,
+			IssueRepo:   link.Repo,
+			IssueNumber: link.IssueNumber,
+			Review:      link.Kind == storage.LinkKindReviewReply,
+			Prefix:      link.Body,
+			AuthorLogin: link.Author,
+		}
+	}
+
+	return nil
+}
+
+// SentParts - returns message IDs of parts sent to chat while handling delivery by part index
+func (b *Bot) SentParts(delivery string, chatID int64) (map[int]int64, error) {
+	return storage.FindSentParts(b.DB, delivery, chatID)
+}
+
+// SaveSentPart - remembers that part of message was sent to chat while handling delivery
+func (b *Bot) SaveSentPart(delivery string, chatID int64, part int, messageID int64) error {
+	return storage.SaveSentPart(b.DB, delivery, chatID, part, messageID)
+}
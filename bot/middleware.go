@@ -0,0 +1,21 @@
+package bot
+
+import (
+	"context"
+	"log"
+)
+
+// FilterMiddleware - drops events denied by filter rules and passes transformed ones further
+type FilterMiddleware struct{}
+
+// Dispatch - applies filter rules to event payload
+func (FilterMiddleware) Dispatch(ctx context.Context, b *Bot, env Envelope, next func(context.Context, Envelope)) {
+	decision := b.Filters.Apply(env.Payload)
+	if !decision.Allowed {
+		log.Printf("Skipping %s %s denied by filter rule %d\n", env.Kind, env.ID, decision.Rule)
+		return
+	}
+	env.Payload = decision.Event
+
+	next(ctx, env)
+}
@@ -0,0 +1,57 @@
+package bot
+
+import (
+	"fmt"
+
+	"github.com/andreyst/tracker-messenger-bridge/messenger"
+	"github.com/andreyst/tracker-messenger-bridge/permissions"
+	"github.com/andreyst/tracker-messenger-bridge/storage"
+)
+
+// Authorize - checks that messenger user has required role in chat for repo ("owner/repo")
+// and records decision to audit log once per event, so that retries of env do not add entries.
+// Returned refusal is a polite text to reply with when user is not allowed to do action
+func (b *Bot) Authorize(env Envelope, user *messenger.User, chatID int64, repo string, action string, required permissions.Role) (bool, string, error) {
+	role := b.Permissions.RoleOf(user.ID, chatID, repo)
+	allowed := role >= required
+
+	err := storage.SaveAuditEntry(b.DB, storage.AuditEntry{
+		EventSource:      env.Source,
+		EventID:          env.ID,
+		TelegramUserID:   int64(user.ID),
+		TelegramUserName: user.UserName,
+		ChatID:           chatID,
+		Repo:             repo,
+		Action:           action,
+		Role:             role.String(),
+		RequiredRole:     required.String(),
+		Allowed:          allowed,
+	})
+	if err != nil {
+		return false, "", fmt.Errorf("unable to save audit entry: %w", err)
+	}
+
+	if allowed {
+		return true, "", nil
+	}
+
+	where := "in this chat"
+	if repo != "" {
+		where = fmt.Sprintf("for %s in this chat", repo)
+	}
+
+	return false, fmt.Sprintf("Sorry, only %s can do this %s, please ask a maintainer for access", rolePlural(required), where), nil
+}
+
+// AuditLog - returns latest authorization decisions, newest first
+func (b *Bot) AuditLog(deniedOnly bool, limit int) ([]storage.AuditEntry, error) {
+	return storage.ListAuditEntries(b.DB, deniedOnly, limit)
+}
+
+func rolePlural(role permissions.Role) string {
+	if role == permissions.Staff {
+		return "staff"
+	}
+
+	return role.String() + "s"
+}
@@ -0,0 +1,280 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andreyst/tracker-messenger-bridge/storage"
+)
+
+// Webhook data is queued with source "webhook:<path>"
+const webhookSourcePrefix = "webhook:"
+
+// How often IDs of processed events older than dedup retention are forgotten
+const processedEventsPruneInterval = time.Hour
+
+// Delay before queue item interrupted by shutdown is processed again
+const queueReleaseDelay = 5 * time.Second
+
+// UnprocessedError - event which can not be processed successfully, e.g. with invalid signature or put to dead letters,
+// such queue items are deleted, but their source IDs are not remembered, so that redeliveries are processed again
+type UnprocessedError struct {
+	Err error
+}
+
+func (e *UnprocessedError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap - returns reason event can not be processed
+func (e *UnprocessedError) Unwrap() error {
+	return e.Err
+}
+
+// RetryError - event failed with transient error and should be processed again after delay,
+// other events are processed meanwhile
+type RetryError struct {
+	Err   error
+	After time.Duration
+}
+
+func (e *RetryError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap - returns error event failed with
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// RetryTransient - returns RetryError for transient errors, e.g. rate limits, until retry.max_attempts is reached,
+// UnprocessedError for other errors and after that, so that queue item is skipped
+func (b *Bot) RetryTransient(ctx context.Context, err error) error {
+	if transient, _ := isTransient(err); !transient {
+		return &UnprocessedError{Err: err}
+	}
+
+	return b.retryLater(attemptOf(ctx), err)
+}
+
+// retryLater - returns RetryError with delay before attempt following failed one until retry.max_attempts is reached,
+// UnprocessedError afterwards
+func (b *Bot) retryLater(attempt int, err error) error {
+	if attempt >= b.Config.Retry.MaxAttempts {
+		return &UnprocessedError{Err: fmt.Errorf("giving up after %d attempts: %w", attempt, err)}
+	}
+
+	return &RetryError{Err: err, After: b.retryDelay(attempt, err)}
+}
+
+// retryDelay - returns exponential backoff before attempt following failed one, or delay requested by remote side if longer
+func (b *Bot) retryDelay(attempt int, err error) time.Duration {
+	delay := b.backoff(attempt)
+	if _, retryAfter := isTransient(err); retryAfter > delay {
+		delay = retryAfter
+	}
+
+	return delay
+}
+
+// attemptKey - context key of attempt to process queue item, starting from 1
+type attemptKey struct{}
+
+func withAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// attemptOf - returns attempt to process queue item event comes from, 1 for events not from queue
+func attemptOf(ctx context.Context) int {
+	if attempt, ok := ctx.Value(attemptKey{}).(int); ok {
+		return attempt
+	}
+
+	return 1
+}
+
+// deadLetterKey - context key of dead letter replayed event comes from
+type deadLetterKey struct{}
+
+func withDeadLetter(ctx context.Context, rowID int64) context.Context {
+	return context.WithValue(ctx, deadLetterKey{}, rowID)
+}
+
+// deadLetterOf - returns row ID of dead letter replayed event comes from, 0 for other events
+func deadLetterOf(ctx context.Context) int64 {
+	rowID, _ := ctx.Value(deadLetterKey{}).(int64)
+	return rowID
+}
+
+// Unprocessed - wraps reason event can not be processed to UnprocessedError
+func Unprocessed(format string, args ...interface{}) error {
+	return &UnprocessedError{Err: fmt.Errorf(format, args...)}
+}
+
+// Enqueue - saves incoming event to queue, to be processed at least once,
+// returns false if event with the same source ID is already queued or was recently processed
+func (b *Bot) Enqueue(item storage.QueueItem) (bool, error) {
+	queued, err := storage.EnqueueEvent(b.DB, item)
+	if err == nil {
+		b.notifyQueue()
+	}
+
+	return queued, err
+}
+
+// EnqueueWithOffset - saves incoming event to queue together with source offset,
+// so that polling can continue from offset after restart
+func (b *Bot) EnqueueWithOffset(item storage.QueueItem, offset int64) (bool, error) {
+	queued, err := storage.EnqueueEventWithOffset(b.DB, item, offset)
+	if err == nil {
+		b.notifyQueue()
+	}
+
+	return queued, err
+}
+
+// SourceOffset - returns offset saved with EnqueueWithOffset
+func (b *Bot) SourceOffset(source string) (int64, error) {
+	return storage.LoadSourceOffset(b.DB, source)
+}
+
+// notifyQueue - tries to notify queue processor, skips if it is already notified
+func (b *Bot) notifyQueue() {
+	select {
+	case b.queueNotifications <- struct{}{}:
+	default:
+	}
+}
+
+func (b *Bot) processQueue(ctx context.Context) error {
+	var prunedAt time.Time
+	for ctx.Err() == nil {
+		if time.Since(prunedAt) >= processedEventsPruneInterval {
+			b.pruneProcessedEvents()
+			prunedAt = time.Now()
+		}
+
+		item, err := storage.LoadQueueItem(b.DB)
+		if err != nil {
+			log.Printf("Unable to load queue item: %v\n", err)
+		}
+		if item == nil {
+			// Wait for new events to process them immediately
+			// or timeout in case some stored events became visible
+			// after previous try
+			select {
+			case <-ctx.Done():
+			case <-b.queueNotifications:
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+
+		log.Printf("Processing queue item %d from %s\n", item.RowID, item.Source)
+
+		err = b.processQueueItem(ctx, *item)
+		var retry *RetryError
+		if err != nil && ctx.Err() == nil && !errors.As(err, &retry) && !errors.As(err, new(*UnprocessedError)) {
+			// Other errors, e.g. failed DB writes, are retried with backoff too, so that item is not reloaded in a loop
+			err = b.retryLater(item.Attempts+1, err)
+		}
+		if errors.As(err, &retry) {
+			log.Printf("Retrying queue item %d in %v: %v\n", item.RowID, retry.After, err)
+			err = storage.RetryQueueItem(b.DB, item.RowID, retry.After)
+			if err != nil {
+				log.Printf("Unable to retry queue item %d: %v\n", item.RowID, err)
+			}
+			continue
+		}
+
+		processed := true
+		var unprocessed *UnprocessedError
+		if errors.As(err, &unprocessed) {
+			log.Printf("Skipping queue item %d: %v\n", item.RowID, err)
+			processed, err = false, nil
+		}
+		if err != nil {
+			// Interrupted by shutdown, make item visible again, so that it is processed after restart
+			log.Printf("Unable to process queue item %d, releasing it: %v\n", item.RowID, err)
+			err = storage.ReleaseQueueItem(b.DB, item.RowID, queueReleaseDelay)
+			if err != nil {
+				log.Printf("Unable to release queue item %d: %v\n", item.RowID, err)
+			}
+			continue
+		}
+
+		err = storage.CompleteQueueItem(b.DB, *item, processed)
+		if err != nil {
+			log.Printf("Unable to complete queue item %d: %v\n", item.RowID, err)
+		}
+	}
+
+	return nil
+}
+
+// pruneProcessedEvents - forgets processed events and sent message parts older than dedup retention,
+// their redeliveries are queued and sent again
+func (b *Bot) pruneProcessedEvents() {
+	count, err := storage.DeleteProcessedEvents(b.DB, b.Config.Queue.DedupRetention)
+	if err != nil {
+		log.Printf("Unable to delete processed events: %v\n", err)
+		return
+	}
+	if count > 0 {
+		log.Printf("Forgot %d processed events older than %v\n", count, b.Config.Queue.DedupRetention)
+	}
+
+	count, err = storage.DeleteSentParts(b.DB, b.Config.Queue.DedupRetention)
+	if err != nil {
+		log.Printf("Unable to delete sent parts: %v\n", err)
+		return
+	}
+	if count > 0 {
+		log.Printf("Forgot %d sent message parts older than %v\n", count, b.Config.Queue.DedupRetention)
+	}
+}
+
+func (b *Bot) processQueueItem(ctx context.Context, item storage.QueueItem) error {
+	ctx = withAttempt(ctx, item.Attempts+1)
+
+	if strings.HasPrefix(item.Source, webhookSourcePrefix) {
+		webhook, ok := b.Webhooks[strings.TrimPrefix(item.Source, webhookSourcePrefix)]
+		if !ok {
+			return Unprocessed("no webhook for %s", item.Source)
+		}
+
+		var headers http.Header
+		err := json.Unmarshal([]byte(item.Meta), &headers)
+		if err != nil {
+			return Unprocessed("unable to parse JSON headers %s: %v", item.Meta, err)
+		}
+
+		r := &http.Request{
+			Method: "POST",
+			Body:   ioutil.NopCloser(bytes.NewReader([]byte(item.Payload))),
+			Header: headers,
+		}
+
+		return webhook.Handle(b, r.WithContext(ctx))
+	}
+
+	poller, ok := b.Pollers[item.Source]
+	if !ok {
+		return Unprocessed("no poller for %s", item.Source)
+	}
+
+	queueHandler, ok := poller.(QueueHandler)
+	if !ok {
+		return fmt.Errorf("poller %s does not handle queue items", item.Source)
+	}
+
+	return queueHandler.HandleQueueItem(ctx, b, item)
+}
@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/andreyst/tracker-messenger-bridge/messenger"
+	"github.com/andreyst/tracker-messenger-bridge/tracker"
+	"github.com/andreyst/tracker-messenger-bridge/tracker/gitea"
+	"github.com/google/go-github/github"
+)
+
+// isTransient - checks whether error is worth retrying, returns delay requested by remote side if any
+func isTransient(err error) (bool, time.Duration) {
+	// Checked first, as Github client wraps transport errors to url.Error, which is net.Error
+	var permanentErr *tracker.PermanentError
+	if errors.As(err, &permanentErr) {
+		return false, 0
+	}
+
+	var rateLimitErr *github.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return true, time.Until(rateLimitErr.Rate.Reset.Time)
+	}
+
+	var abuseRateLimitErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseRateLimitErr) {
+		if abuseRateLimitErr.RetryAfter != nil {
+			return true, *abuseRateLimitErr.RetryAfter
+		}
+		return true, 0
+	}
+
+	var acceptedErr *github.AcceptedError
+	if errors.As(err, &acceptedErr) {
+		return true, 0
+	}
+
+	var errorResponse *github.ErrorResponse
+	if errors.As(err, &errorResponse) {
+		return errorResponse.Response != nil && errorResponse.Response.StatusCode >= 500, 0
+	}
+
+	var giteaErr *gitea.Error
+	if errors.As(err, &giteaErr) {
+		return giteaErr.StatusCode >= 500 || giteaErr.StatusCode == http.StatusTooManyRequests, 0
+	}
+
+	var messengerErr *messenger.RateLimitError
+	if errors.As(err, &messengerErr) {
+		return true, messengerErr.RetryAfter
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true, 0
+	}
+
+	return false, 0
+}
@@ -0,0 +1,41 @@
+package bot
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSealToken(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+
+	sealed, err := sealToken(key, "ghp_secret")
+	if err != nil {
+		t.Fatalf("sealToken() error = %v", err)
+	}
+	if sealed == "ghp_secret" || !bytes.HasPrefix([]byte(sealed), []byte(encryptedTokenPrefix)) {
+		t.Fatalf("sealToken() = %q, want encrypted token", sealed)
+	}
+
+	tests := []struct {
+		name    string
+		key     []byte
+		stored  string
+		want    string
+		wantErr bool
+	}{
+		{"encrypted token", key, sealed, "ghp_secret", false},
+		{"plaintext token saved before key was configured", key, "ghp_plain", "ghp_plain", false},
+		{"plaintext token without key", nil, "ghp_plain", "ghp_plain", false},
+		{"encrypted token without key", nil, sealed, "", true},
+		{"encrypted token with other key", bytes.Repeat([]byte{8}, 32), sealed, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := openToken(tt.key, tt.stored)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("openToken() = %q, %v, want %q, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
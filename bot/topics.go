@@ -0,0 +1,97 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/andreyst/tracker-messenger-bridge/messenger"
+	"github.com/andreyst/tracker-messenger-bridge/storage"
+)
+
+// Max length of topic name, Telegram limit
+const maxTopicNameLength = 128
+
+// UsesTopics - checks whether every issue gets its own topic in chat
+func (b *Bot) UsesTopics(chatID int64) bool {
+	if _, ok := b.Messenger.(messenger.Topics); !ok {
+		return false
+	}
+
+	for _, id := range b.Config.Telegram.TopicChats {
+		if id == chatID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CreateIssueTopic - creates topic for issue or pull request in chat and returns its thread ID
+func (b *Bot) CreateIssueTopic(ctx context.Context, chatID int64, owner string, repo string, number int64, title string) (int64, error) {
+	topics, ok := b.Messenger.(messenger.Topics)
+	if !ok {
+		return 0, fmt.Errorf("%s does not support topics", b.Messenger.Name())
+	}
+
+	name := []rune(fmt.Sprintf("#%d %s", number, title))
+	if len(name) > maxTopicNameLength {
+		name = append(name[:maxTopicNameLength-1], '…')
+	}
+
+	threadID, err := topics.CreateTopic(ctx, chatID, string(name))
+	if err != nil {
+		return 0, fmt.Errorf("unable to create topic in chat %d: %w", chatID, err)
+	}
+
+	err = storage.SaveIssueTopic(b.DB, storage.IssueTopic{
+		ChatID:      chatID,
+		Owner:       owner,
+		Repo:        repo,
+		IssueNumber: number,
+		ThreadID:    threadID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("unable to save topic %d: %w", threadID, err)
+	}
+
+	return threadID, nil
+}
+
+// IssueTopics - returns thread IDs of topics of issue or pull request by chat
+func (b *Bot) IssueTopics(owner string, repo string, number int64) (map[int64]int64, error) {
+	topics, err := storage.FindIssueTopics(b.DB, owner, repo, number)
+	if err != nil {
+		return nil, err
+	}
+
+	threads := make(map[int64]int64)
+	for _, topic := range topics {
+		threads[topic.ChatID] = topic.ThreadID
+	}
+
+	return threads, nil
+}
+
+// SetIssueTopicsClosed - closes or reopens topics of issue or pull request in all chats,
+// continues to other chats after errors, first error is returned
+func (b *Bot) SetIssueTopicsClosed(ctx context.Context, owner string, repo string, number int64, closed bool) error {
+	messengerTopics, ok := b.Messenger.(messenger.Topics)
+	if !ok {
+		return nil
+	}
+
+	topics, err := storage.FindIssueTopics(b.DB, owner, repo, number)
+	if err != nil {
+		return fmt.Errorf("unable to look up topics: %w", err)
+	}
+
+	var firstErr error
+	for _, topic := range topics {
+		err := messengerTopics.SetTopicClosed(ctx, topic.ChatID, topic.ThreadID, closed)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("unable to update topic %d in chat %d: %w", topic.ThreadID, topic.ChatID, err)
+		}
+	}
+
+	return firstErr
+}
@@ -0,0 +1,237 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	bot "github.com/andreyst/tracker-messenger-bridge/bot"
+	"github.com/andreyst/tracker-messenger-bridge/messenger"
+	"github.com/andreyst/tracker-messenger-bridge/permissions"
+)
+
+// Kinds of targets commands act on
+const (
+	// TargetNone - command does not need a target
+	TargetNone = iota
+	// TargetRepo - command needs a repository, given as owner/repo or taken from replied message
+	TargetRepo
+	// TargetIssue - command needs an issue, given as owner/repo#N or taken from replied message
+	TargetIssue
+)
+
+// Target - repository or issue command acts on
+type Target struct {
+	Owner  string
+	Repo   string
+	Number int64
+}
+
+func (t Target) String() string {
+	if t.Number == 0 {
+		return fmt.Sprintf("%s/%s", t.Owner, t.Repo)
+	}
+	return fmt.Sprintf("%s/%s#%d", t.Owner, t.Repo, t.Number)
+}
+
+// Command - parsed messenger command
+type Command struct {
+	// Command name without slash and bot username
+	Name string
+	// Arguments after command name and explicit target
+	Args string
+	// Target resolved according to Spec.Target
+	Target Target
+	// Message command was sent in
+	Message *messenger.Message
+	// Message to reply to with command result, defaults to command message, 0 for none
+	ReplyToMessageID int64
+}
+
+// Func - executes command and returns text to confirm it in chat, ctx is cancelled on shutdown
+type Func func(ctx context.Context, b *bot.Bot, cmd *Command) (string, error)
+
+// Spec - command description
+type Spec struct {
+	Name   string
+	Usage  string
+	Target int
+	// Lowest role allowed to run command
+	Role permissions.Role
+	Run  Func
+}
+
+// Registry - known commands
+type Registry struct {
+	specs map[string]Spec
+}
+
+// NewRegistry - creates registry with given commands
+func NewRegistry(specs ...Spec) *Registry {
+	r := &Registry{specs: make(map[string]Spec)}
+	for _, spec := range specs {
+		r.Register(spec)
+	}
+
+	return r
+}
+
+// Register - adds command to registry, replacing command with the same name
+func (r *Registry) Register(spec Spec) {
+	r.specs[spec.Name] = spec
+}
+
+// Lookup - finds command by name
+func (r *Registry) Lookup(name string) (Spec, bool) {
+	spec, ok := r.specs[name]
+	return spec, ok
+}
+
+// Usage - lists usage of all commands
+func (r *Registry) Usage() string {
+	var lines []string
+	for _, spec := range r.specs {
+		lines = append(lines, spec.Usage)
+	}
+	sort.Strings(lines)
+
+	return strings.Join(lines, "\n")
+}
+
+var commandRe = regexp.MustCompile(`^/([A-Za-z0-9_]+)(?:@([A-Za-z0-9_]+))?(?:\s+([\s\S]*))?$`)
+
+// Parse - parses "/name@bot args" text, commands addressed to other bots are ignored
+func Parse(text string, botUserName string) (name string, args string, ok bool) {
+	m := commandRe.FindStringSubmatch(strings.TrimSpace(text))
+	if m == nil {
+		return "", "", false
+	}
+	if m[2] != "" && !strings.EqualFold(m[2], botUserName) {
+		return "", "", false
+	}
+
+	return strings.ToLower(m[1]), strings.TrimSpace(m[3]), true
+}
+
+var targetRe = regexp.MustCompile(`^([A-Za-z0-9_.-]+)/([A-Za-z0-9_.-]+)(?:#([0-9]+))?$`)
+
+// ParseTarget - parses "owner/repo" or "owner/repo#N"
+func ParseTarget(s string) (Target, bool) {
+	m := targetRe.FindStringSubmatch(s)
+	if m == nil {
+		return Target{}, false
+	}
+
+	target := Target{Owner: m[1], Repo: m[2]}
+	if m[3] != "" {
+		number, err := strconv.ParseInt(m[3], 10, 64)
+		if err != nil {
+			return Target{}, false
+		}
+		target.Number = number
+	}
+
+	return target, true
+}
+
+// resolveTarget - takes explicit target from the first argument or from bridged message command replies to
+func resolveTarget(b *bot.Bot, cmd *Command, kind int) error {
+	first := strings.Fields(cmd.Args)
+	if len(first) > 0 {
+		if target, ok := ParseTarget(first[0]); ok {
+			if kind == TargetIssue && target.Number == 0 {
+				return fmt.Errorf("expected owner/repo#N, got %s", first[0])
+			}
+			cmd.Target = target
+			cmd.Args = strings.TrimSpace(strings.TrimPrefix(cmd.Args, first[0]))
+			return nil
+		}
+	}
+
+	if cmd.Message.ReplyTo == nil {
+		return fmt.Errorf("reply to a bridged message or pass owner/repo#N")
+	}
+
+	source, err := b.LinkedObject(cmd.Message.ChatID, cmd.Message.ReplyTo.ID)
+	if err != nil {
+		return fmt.Errorf("unable to look up replied message: %w", err)
+	}
+
+	switch obj := source.(type) {
+	case bot.Issue:
+		cmd.Target = Target{Owner: obj.Owner, Repo: obj.Repo, Number: obj.Number}
+	case bot.Comment:
+		cmd.Target = Target{Owner: obj.IssueOwner, Repo: obj.IssueRepo, Number: obj.IssueNumber}
+	case bot.ReviewComment:
+		cmd.Target = Target{Owner: obj.Owner, Repo: obj.Repo, Number: obj.PullNumber}
+	case bot.Reply:
+		cmd.Target = Target{Owner: obj.IssueOwner, Repo: obj.IssueRepo, Number: obj.IssueNumber}
+	default:
+		return fmt.Errorf("replied message is not bridged, pass owner/repo#N")
+	}
+
+	if kind == TargetRepo {
+		cmd.Target.Number = 0
+	}
+
+	return nil
+}
+
+// Execute - parses text of message received in env and runs matching command,
+// returns nil command if message is not a known command.
+// Returned reply confirms command or explains its usage, error means command failed
+func (r *Registry) Execute(ctx context.Context, b *bot.Bot, env bot.Envelope, message *messenger.Message) (*Command, string, error) {
+	name, args, ok := Parse(message.Text, b.UserName)
+	if !ok {
+		return nil, "", nil
+	}
+	if message.From == nil {
+		// Channel posts have no author to act on behalf of
+		return nil, "", nil
+	}
+
+	cmd := &Command{
+		Name:             name,
+		Args:             args,
+		Message:          message,
+		ReplyToMessageID: message.ID,
+	}
+
+	if name == "help" {
+		return cmd, r.Usage(), nil
+	}
+
+	spec, ok := r.Lookup(name)
+	if !ok {
+		return nil, "", nil
+	}
+
+	if spec.Target != TargetNone {
+		err := resolveTarget(b, cmd, spec.Target)
+		if err != nil {
+			return cmd, fmt.Sprintf("Usage: %s\n%v", spec.Usage, err), nil
+		}
+	}
+
+	if spec.Role > permissions.Viewer {
+		var repo string
+		if cmd.Target.Owner != "" {
+			repo = cmd.Target.Owner + "/" + cmd.Target.Repo
+		}
+
+		allowed, refusal, err := b.Authorize(env, message.From, message.ChatID, repo, "/"+name, spec.Role)
+		if err != nil {
+			return cmd, "", err
+		}
+		if !allowed {
+			return cmd, refusal, nil
+		}
+	}
+
+	reply, err := spec.Run(ctx, b, cmd)
+
+	return cmd, reply, err
+}
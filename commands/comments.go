@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	bot "github.com/andreyst/tracker-messenger-bridge/bot"
+	"github.com/andreyst/tracker-messenger-bridge/permissions"
+	"github.com/andreyst/tracker-messenger-bridge/tracker"
+)
+
+// Delete - deletes tracker comment created from replied messenger message together with the message
+// Telegram does not tell bots about deleted messages, so deletions are mirrored with this command
+var Delete = Spec{
+	Name:   "delete",
+	Usage:  "/delete - reply to your bridged message to delete it and its tracker comment",
+	Target: TargetIssue,
+	Role:   permissions.Staff,
+	Run: func(ctx context.Context, b *bot.Bot, cmd *Command) (string, error) {
+		replied := cmd.Message.ReplyTo
+		if replied == nil {
+			return "Usage: /delete as a reply to your bridged message", nil
+		}
+
+		chatID := cmd.Message.ChatID
+		obj, err := b.LinkedObject(chatID, replied.ID)
+		if err != nil {
+			return "", err
+		}
+		reply, ok := obj.(bot.Reply)
+		if !ok || replied.From == nil || replied.From.ID != cmd.Message.From.ID {
+			return "Only your own messages posted to tracker can be deleted", nil
+		}
+
+		tr, err := b.ReplyTracker(cmd.Message.From.ID, reply)
+		if err != nil {
+			return "", fmt.Errorf("unable to look up identity: %w", err)
+		}
+		if tr == nil {
+			return fmt.Sprintf("This message was posted as %s, link that account again to delete it", reply.AuthorLogin), nil
+		}
+
+		if reply.Review {
+			reviews, ok := tr.(tracker.ReviewComments)
+			if !ok {
+				return "", fmt.Errorf("review comments not supported by %s", tr.Name())
+			}
+			err = reviews.DeleteReviewComment(ctx, reply.IssueOwner, reply.IssueRepo, reply.CommentID)
+		} else {
+			err = tr.DeleteComment(ctx, reply.IssueOwner, reply.IssueRepo, reply.CommentID)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if reply.Review {
+			err = b.UnlinkReviewComment(reply.CommentID)
+		} else {
+			err = b.UnlinkComment(reply.CommentID)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		// Deleting messages of users requires admin rights, comment is deleted anyway
+		for _, messageID := range []int64{replied.ID, cmd.Message.ID} {
+			err = b.Messenger.Delete(ctx, chatID, messageID)
+			if err != nil {
+				fmt.Printf("Error deleting %s message: %v\n", b.Messenger.Name(), err)
+			}
+		}
+
+		cmd.ReplyToMessageID = 0
+		return fmt.Sprintf("🗑 Deleted comment on %s", cmd.Target), nil
+	},
+}
@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	bot "github.com/andreyst/tracker-messenger-bridge/bot"
+	"github.com/andreyst/tracker-messenger-bridge/config"
+	"github.com/andreyst/tracker-messenger-bridge/identity"
+	"github.com/andreyst/tracker-messenger-bridge/messenger"
+	"github.com/andreyst/tracker-messenger-bridge/storage"
+)
+
+// Link - links messenger user to tracker account, so that replies and commands are posted as that account
+// Accepts personal access token or starts Github OAuth device flow when called without token, both in private chat only
+var Link = Spec{
+	Name:   "link",
+	Usage:  "/link [token] - link your tracker account, token is accepted in private chat only",
+	Target: TargetNone,
+	Run: func(ctx context.Context, b *bot.Bot, cmd *Command) (string, error) {
+		token := strings.TrimSpace(cmd.Args)
+		if token == "" {
+			return startDeviceFlow(ctx, b, cmd)
+		}
+
+		if !cmd.Message.Private {
+			// Do not leave token in group chat history
+			err := b.Messenger.Delete(ctx, cmd.Message.ChatID, cmd.Message.ID)
+			if err != nil {
+				fmt.Printf("Error deleting message with token: %v\n", err)
+			}
+			cmd.ReplyToMessageID = 0
+			return "Tokens are accepted in private chat with the bot only. Please revoke the token you have just sent.", nil
+		}
+
+		login, err := linkToken(ctx, b, cmd.Message.From, token)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("🔗 Linked tracker account %s", login), nil
+	},
+}
+
+// Unlink - forgets tracker account of messenger user
+var Unlink = Spec{
+	Name:   "unlink",
+	Usage:  "/unlink - unlink your tracker account",
+	Target: TargetNone,
+	Run: func(ctx context.Context, b *bot.Bot, cmd *Command) (string, error) {
+		ok, err := b.UnlinkIdentity(cmd.Message.From.ID)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "Your tracker account is not linked", nil
+		}
+
+		return "Tracker account unlinked", nil
+	},
+}
+
+// WhoAmI - shows tracker account linked to messenger user
+var WhoAmI = Spec{
+	Name:   "whoami",
+	Usage:  "/whoami - show your linked tracker account",
+	Target: TargetNone,
+	Run: func(ctx context.Context, b *bot.Bot, cmd *Command) (string, error) {
+		id, err := b.Identity(cmd.Message.From.ID)
+		if err != nil {
+			return "", err
+		}
+		if id == nil {
+			return "Your tracker account is not linked, use /link", nil
+		}
+
+		return fmt.Sprintf("Your tracker account is %s", id.GithubLogin), nil
+	},
+}
+
+// linkToken - checks token and saves identity, returns tracker login
+func linkToken(ctx context.Context, b *bot.Bot, user *messenger.User, token string) (string, error) {
+	login, err := b.Tracker.As(token).CurrentUser(ctx)
+	if err != nil {
+		return "", fmt.Errorf("unable to check token: %w", err)
+	}
+
+	err = b.LinkIdentity(storage.Identity{
+		TelegramUserID:   int64(user.ID),
+		TelegramUserName: user.UserName,
+		GithubLogin:      login,
+		GithubToken:      token,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return login, nil
+}
+
+// startDeviceFlow - asks user to enter code on Github and links account in background once user does
+func startDeviceFlow(ctx context.Context, b *bot.Bot, cmd *Command) (string, error) {
+	clientID := b.Config.Github.OAuthClientID
+	if clientID == "" || b.Config.Tracker != config.TrackerGithub {
+		return "Usage: /link <personal access token> in private chat with the bot", nil
+	}
+	if !cmd.Message.Private {
+		// Device code links the account of whoever enters it first, so it is not shown to the whole group
+		return "Send /link in private chat with the bot", nil
+	}
+
+	code, err := identity.RequestDeviceCode(ctx, clientID)
+	if err != nil {
+		return "", err
+	}
+
+	user := cmd.Message.From
+	chatID := cmd.Message.ChatID
+	b.Go(func(ctx context.Context) {
+		var text string
+		token, err := identity.WaitForToken(ctx, clientID, code)
+		if err == nil {
+			var login string
+			login, err = linkToken(ctx, b, user, token)
+			text = fmt.Sprintf("🔗 @%s linked tracker account %s", user.UserName, login)
+		}
+		if err != nil {
+			text = fmt.Sprintf("Unable to link tracker account of @%s: %v", user.UserName, err)
+		}
+
+		if ctx.Err() != nil {
+			// Bot is shutting down, user has to run /link again
+			return
+		}
+		_, err = b.Messenger.Send(ctx, messenger.Outgoing{ChatID: chatID, Text: text})
+		if err != nil {
+			fmt.Printf("Error sending /link result: %v\n", err)
+		}
+	})
+
+	return fmt.Sprintf("Open %s and enter code %s to link your Github account", code.VerificationURI, code.UserCode), nil
+}
@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	bot "github.com/andreyst/tracker-messenger-bridge/bot"
+	"github.com/andreyst/tracker-messenger-bridge/permissions"
+	"github.com/andreyst/tracker-messenger-bridge/tracker"
+)
+
+// Close - closes issue
+var Close = Spec{
+	Name:   "close",
+	Usage:  "/close [owner/repo#N] - close issue",
+	Target: TargetIssue,
+	Role:   permissions.Maintainer,
+	Run: func(ctx context.Context, b *bot.Bot, cmd *Command) (string, error) {
+		return setClosed(ctx, b, cmd, true, "✅ Closed")
+	},
+}
+
+// Reopen - reopens issue
+var Reopen = Spec{
+	Name:   "reopen",
+	Usage:  "/reopen [owner/repo#N] - reopen issue",
+	Target: TargetIssue,
+	Role:   permissions.Maintainer,
+	Run: func(ctx context.Context, b *bot.Bot, cmd *Command) (string, error) {
+		return setClosed(ctx, b, cmd, false, "🔄 Reopened")
+	},
+}
+
+// Label - adds labels to issue
+var Label = Spec{
+	Name:   "label",
+	Usage:  "/label [owner/repo#N] <label>... - add labels to issue",
+	Target: TargetIssue,
+	Role:   permissions.Staff,
+	Run: func(ctx context.Context, b *bot.Bot, cmd *Command) (string, error) {
+		labels := strings.Fields(cmd.Args)
+		if len(labels) == 0 {
+			return "Usage: /label [owner/repo#N] <label>...", nil
+		}
+
+		tr, err := trackerClient(b, cmd)
+		if err != nil {
+			return "", err
+		}
+
+		t := cmd.Target
+		err = tr.AddLabels(ctx, t.Owner, t.Repo, t.Number, labels)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("🏷 Labeled %s with %s", t, strings.Join(labels, ", ")), nil
+	},
+}
+
+// Assign - assigns users to issue
+var Assign = Spec{
+	Name:   "assign",
+	Usage:  "/assign [owner/repo#N] @user... - assign tracker users to issue",
+	Target: TargetIssue,
+	Role:   permissions.Staff,
+	Run: func(ctx context.Context, b *bot.Bot, cmd *Command) (string, error) {
+		var users []string
+		for _, user := range strings.Fields(cmd.Args) {
+			users = append(users, strings.TrimPrefix(user, "@"))
+		}
+		if len(users) == 0 {
+			return "Usage: /assign [owner/repo#N] @user...", nil
+		}
+
+		tr, err := trackerClient(b, cmd)
+		if err != nil {
+			return "", err
+		}
+
+		t := cmd.Target
+		err = tr.AddAssignees(ctx, t.Owner, t.Repo, t.Number, users)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("👤 Assigned %s to %s", t, strings.Join(users, ", ")), nil
+	},
+}
+
+// New - creates issue, title is taken from the first line and description from the rest
+var New = Spec{
+	Name:   "new",
+	Usage:  "/new [owner/repo] <title> - create issue, following lines become description",
+	Target: TargetRepo,
+	Role:   permissions.Staff,
+	Run: func(ctx context.Context, b *bot.Bot, cmd *Command) (string, error) {
+		lines := strings.SplitN(cmd.Args, "\n", 2)
+		title := strings.TrimSpace(lines[0])
+		if title == "" {
+			return "Usage: /new [owner/repo] <title>", nil
+		}
+		var body string
+		if len(lines) > 1 {
+			body = strings.TrimSpace(lines[1])
+		}
+
+		tr, err := trackerClient(b, cmd)
+		if err != nil {
+			return "", err
+		}
+
+		t := cmd.Target
+		number, url, err := tr.CreateIssue(ctx, t.Owner, t.Repo, title, body)
+		if err != nil {
+			return "", err
+		}
+
+		t.Number = number
+		return fmt.Sprintf("🆕 Created %s %s", t, url), nil
+	},
+}
+
+func setClosed(ctx context.Context, b *bot.Bot, cmd *Command, closed bool, verb string) (string, error) {
+	tr, err := trackerClient(b, cmd)
+	if err != nil {
+		return "", err
+	}
+
+	t := cmd.Target
+	err = tr.SetClosed(ctx, t.Owner, t.Repo, t.Number, closed)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s %s", verb, t), nil
+}
+
+// trackerClient - returns client acting as command author if author linked tracker account, bot client otherwise
+func trackerClient(b *bot.Bot, cmd *Command) (tracker.Tracker, error) {
+	tr, _, err := b.TrackerFor(cmd.Message.From.ID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to look up identity: %w", err)
+	}
+
+	return tr, nil
+}
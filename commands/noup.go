@@ -0,0 +1,23 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	bot "github.com/andreyst/tracker-messenger-bridge/bot"
+)
+
+// NoUp - explains channel bumping policy
+var NoUp = Spec{
+	Name:   "noup",
+	Usage:  "/noup - reply to a message to explain channel bumping policy",
+	Target: TargetNone,
+	Run: func(ctx context.Context, b *bot.Bot, cmd *Command) (string, error) {
+		if cmd.Message.ReplyTo == nil {
+			return "Please do not bump!", nil
+		}
+
+		cmd.ReplyToMessageID = cmd.Message.ReplyTo.ID
+		return fmt.Sprintf("@%s Please do not bump!", cmd.Message.From.UserName), nil
+	},
+}
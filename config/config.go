@@ -0,0 +1,800 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config - bridge configuration
+// Values are loaded from YAML file and can be overridden by env variables
+type Config struct {
+	// Port to serve webhooks on, env: PORT
+	Port int `yaml:"port"`
+	// Sqlite database path, env: DB_PATH
+	DBPath string `yaml:"db_path"`
+	// Base64 encoded 32 byte key tracker tokens linked with /link are encrypted with in database,
+	// tokens are stored in plaintext if empty, env: TOKEN_ENCRYPTION_KEY
+	TokenEncryptionKey string `yaml:"token_encryption_key"`
+
+	// Messenger issues are bridged to: "telegram" or "slack", env: MESSENGER
+	Messenger string `yaml:"messenger"`
+
+	// Tracker issues are bridged from: "github" or "gitea", env: TRACKER
+	Tracker string `yaml:"tracker"`
+
+	Telegram TelegramConfig `yaml:"telegram"`
+	Slack    SlackConfig    `yaml:"slack"`
+	Github   GithubConfig   `yaml:"github"`
+	Gitea    GiteaConfig    `yaml:"gitea"`
+	Retry    RetryConfig    `yaml:"retry"`
+	Queue    QueueConfig    `yaml:"queue"`
+
+	Attachments AttachmentsConfig `yaml:"attachments"`
+
+	// Routes from repositories to chats, events go to chats of all matching routes,
+	// events not matching any route go to telegram.chat_id or slack.chat_id
+	Routes []Route `yaml:"routes"`
+
+	// Roles of Telegram users, users without grants are viewers
+	// and cannot change anything on Github
+	Permissions []Grant `yaml:"permissions"`
+
+	// Rules deciding which Github events are bridged, checked in order before event handlers,
+	// events not denied by any rule are bridged
+	Filters []FilterRule `yaml:"filters"`
+
+	// Overrides of default message templates for repos or chats,
+	// first matching set defining template for event wins
+	Templates []TemplateSet `yaml:"templates"`
+}
+
+// TelegramConfig - Telegram related configuration
+type TelegramConfig struct {
+	// Token for Telegram bot, env: TELEGRAM_TOKEN
+	Token string `yaml:"token"`
+	// Default chat to bridge issues to, env: TELEGRAM_CHAT_ID
+	ChatID int64 `yaml:"chat_id"`
+	// How to receive updates: "polling" or "webhook", env: TELEGRAM_MODE
+	Mode string `yaml:"mode"`
+	// Public HTTPS URL Telegram should send updates to in webhook mode, env: TELEGRAM_WEBHOOK_URL
+	WebhookURL string `yaml:"webhook_url"`
+	// Path to serve Telegram webhook on, env: TELEGRAM_WEBHOOK_PATH
+	WebhookPath string `yaml:"webhook_path"`
+	// Secret token Telegram sends with every update in webhook mode, env: TELEGRAM_WEBHOOK_SECRET
+	WebhookSecret string `yaml:"webhook_secret"`
+	// Forum supergroups where every issue and pull request gets its own topic,
+	// bot has to be administrator allowed to manage topics there
+	TopicChats []int64 `yaml:"topic_chats"`
+	// Max number of messages long issue or comment is split into, the last one links to Github if text still does not fit,
+	// used with Slack too
+	MaxMessageParts int `yaml:"max_message_parts"`
+}
+
+// SlackConfig - Slack related configuration, used when messenger is "slack"
+type SlackConfig struct {
+	// Bot token of Slack app, xoxb-..., env: SLACK_TOKEN
+	Token string `yaml:"token"`
+	// Signing secret of Slack app, env: SLACK_SIGNING_SECRET
+	SigningSecret string `yaml:"signing_secret"`
+	// Path to serve Events API requests on, env: SLACK_EVENTS_PATH
+	EventsPath string `yaml:"events_path"`
+	// Numbers of bridged channels by Slack channel ID, e.g. C0123ABC: 1,
+	// numbers are used as chat IDs in routes, permissions and templates
+	Channels map[string]int64 `yaml:"channels"`
+	// Default chat to bridge issues to, one of channel numbers
+	ChatID int64 `yaml:"chat_id"`
+}
+
+// Messengers
+const (
+	MessengerTelegram = "telegram"
+	MessengerSlack    = "slack"
+)
+
+// Telegram update receiving modes
+const (
+	TelegramModePolling = "polling"
+	TelegramModeWebhook = "webhook"
+)
+
+// GithubConfig - Github related configuration
+type GithubConfig struct {
+	// Github OAuth token to post comments with, env: GITHUB_TOKEN, not used when app_id is set
+	Token string `yaml:"token"`
+	// ID of Github App to act as instead of token owner, env: GITHUB_APP_ID
+	AppID int64 `yaml:"app_id"`
+	// Path to PEM private key of Github App, env: GITHUB_APP_PRIVATE_KEY_PATH
+	AppPrivateKeyPath string `yaml:"app_private_key_path"`
+	// Installation of Github App used for requests not related to repository, optional when app is installed once,
+	// env: GITHUB_APP_INSTALLATION_ID
+	AppInstallationID int64 `yaml:"app_installation_id"`
+	// Github webhook secret, env: GITHUB_WEBHOOK_SECRET
+	WebhookSecret string `yaml:"webhook_secret"`
+	// Path to serve Github webhook on, env: GITHUB_WEBHOOK_PATH
+	WebhookPath string `yaml:"webhook_path"`
+	// Client ID of Github OAuth app with device flow enabled, used by /link, env: GITHUB_OAUTH_CLIENT_ID
+	OAuthClientID string `yaml:"oauth_client_id"`
+}
+
+// GiteaConfig - Gitea related configuration, used when tracker is "gitea"
+type GiteaConfig struct {
+	// Base URL of Gitea instance, e.g. https://gitea.example.com, env: GITEA_URL
+	URL string `yaml:"url"`
+	// Gitea access token to post comments with, env: GITEA_TOKEN
+	Token string `yaml:"token"`
+	// Gitea webhook secret, env: GITEA_WEBHOOK_SECRET
+	WebhookSecret string `yaml:"webhook_secret"`
+	// Path to serve Gitea webhook on, env: GITEA_WEBHOOK_PATH
+	WebhookPath string `yaml:"webhook_path"`
+}
+
+// Trackers
+const (
+	TrackerGithub = "github"
+	TrackerGitea  = "gitea"
+)
+
+// AttachmentsConfig - storage for files attached to Telegram replies, which are linked from Github comments
+type AttachmentsConfig struct {
+	// Storage backend: "local", attachments are not bridged when empty, env: ATTACHMENTS_BACKEND
+	Backend string `yaml:"backend"`
+	// Directory to store files in with local backend, env: ATTACHMENTS_DIR
+	Dir string `yaml:"dir"`
+	// Public URL stored files are available at, e.g. https://bridge.example.com/files, env: ATTACHMENTS_URL
+	URL string `yaml:"url"`
+	// Path to serve files on with local backend, env: ATTACHMENTS_PATH
+	Path string `yaml:"path"`
+	// Max size of bridged file in bytes, Telegram Bot API does not allow bots to download files over 20 MB
+	MaxSize int `yaml:"max_size"`
+}
+
+// Attachment storage backends
+const (
+	AttachmentsBackendLocal = "local"
+)
+
+// QueueConfig - queue of incoming webhook deliveries and polled updates
+type QueueConfig struct {
+	// How long IDs of processed deliveries are kept, so that redeliveries are skipped, e.g. X-GitHub-Delivery,
+	// Github allows redelivering webhooks for 3 days
+	DedupRetention time.Duration `yaml:"dedup_retention"`
+}
+
+// RetryConfig - retries of event handlers failed with transient errors
+type RetryConfig struct {
+	// Attempts before event is put to dead letters
+	MaxAttempts int `yaml:"max_attempts"`
+	// Delay before second attempt, doubled for every next attempt
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	// Max delay between attempts
+	MaxBackoff time.Duration `yaml:"max_backoff"`
+}
+
+// Route - routes Github events to chats
+type Route struct {
+	// owner/repo patterns, e.g. "andreyst/*"
+	Repos []string `yaml:"repos"`
+	// Optional Github event types, e.g. "issues", "issue_comment", "pull_request",
+	// "pull_request_review" or "pull_request_review_comment", any type matches when empty
+	Events []string `yaml:"events"`
+	// Optional issue labels, route matches when issue has any of them
+	Labels []string `yaml:"labels"`
+	// Chats to send matched events to
+	Chats []int64 `yaml:"chats"`
+	// How chats get events: "realtime" (default) posts every event, "digest" posts
+	// summaries on schedule, "both" does both
+	Mode string `yaml:"mode"`
+	// Digest schedule in local time: "hourly" or "daily HH:MM", required in digest and both modes
+	Schedule string `yaml:"schedule"`
+}
+
+// Route modes
+const (
+	RouteModeRealtime = "realtime"
+	RouteModeDigest   = "digest"
+	RouteModeBoth     = "both"
+)
+
+// Grant - gives role to Telegram users
+type Grant struct {
+	// Numeric Telegram user IDs or Slack member IDs, e.g. U0123ABCDEF,
+	// usernames are not accepted as they can be changed and taken by someone else
+	Users []string `yaml:"users"`
+	// "viewer", "staff" or "maintainer"
+	Role string `yaml:"role"`
+	// Optional chats grant applies in, any chat matches when empty
+	Chats []int64 `yaml:"chats"`
+	// Optional owner/repo patterns grant applies to, any repo matches when empty
+	Repos []string `yaml:"repos"`
+}
+
+// FilterRule - matches Github events and allows, denies or transforms them
+// Rule matches when all of its conditions match, omitted conditions match any event
+type FilterRule struct {
+	// owner/repo patterns, e.g. "andreyst/*"
+	Repos []string `yaml:"repos"`
+	// Github event types, e.g. "issues" or "issue_comment"
+	Events []string `yaml:"events"`
+	// Event actions, e.g. "opened" or "created"
+	Actions []string `yaml:"actions"`
+	// Logins of users who triggered event, * matches any characters, e.g. "*[bot]"
+	Senders []string `yaml:"senders"`
+	// Issue or pull request labels, rule matches when issue has any of them
+	Labels []string `yaml:"labels"`
+	// Regular expression matched against issue, pull request, comment or review body
+	Body string `yaml:"body"`
+	// Issue or pull request states, "open" or "closed"
+	States []string `yaml:"states"`
+
+	// "allow" and "deny" stop checking rules, "transform" applies replacements
+	// to body and goes on to the next rules
+	Outcome string `yaml:"outcome"`
+	// Body replacements of transform outcome
+	Replace []Replacement `yaml:"replace"`
+}
+
+// Replacement - replaces regular expression matches, $1 in With refers to submatch
+type Replacement struct {
+	Pattern string `yaml:"pattern"`
+	With    string `yaml:"with"`
+}
+
+// Filter outcomes
+const (
+	FilterAllow     = "allow"
+	FilterDeny      = "deny"
+	FilterTransform = "transform"
+)
+
+// TemplateSet - Go text/template templates of Telegram messages for repos or chats
+type TemplateSet struct {
+	// Optional owner/repo patterns, any repo matches when empty
+	Repos []string `yaml:"repos"`
+	// Optional chats, any chat matches when empty
+	Chats []int64 `yaml:"chats"`
+	// Templates by event type and action, e.g. "issues.opened" or "issue_comment.created"
+	Messages map[string]string `yaml:"messages"`
+}
+
+// Roles of Telegram users, each role can do everything lower roles can
+const (
+	// RoleViewer can read bridged messages and use commands which do not touch Github
+	RoleViewer = "viewer"
+	// RoleStaff can reply to issues, label, assign and create them
+	RoleStaff = "staff"
+	// RoleMaintainer can also close and reopen issues
+	RoleMaintainer = "maintainer"
+)
+
+var scheduleRe = regexp.MustCompile(`^(hourly|daily ([01][0-9]|2[0-3]):[0-5][0-9])$`)
+
+var telegramSecretRe = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+var grantUserRe = regexp.MustCompile(`^([0-9]+|[UW][A-Z0-9]+)$`)
+
+// ValidationError - lists all problems found in configuration
+type ValidationError []string
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration:\n  %s", strings.Join(e, "\n  "))
+}
+
+// Load - loads configuration from YAML file at path, applies env overrides and validates result
+// Empty path means configuration is read from env variables only
+func Load(path string) (*Config, error) {
+	cfg := &Config{
+		Messenger: MessengerTelegram,
+		Tracker:   TrackerGithub,
+		Telegram: TelegramConfig{
+			Mode:            TelegramModePolling,
+			WebhookPath:     "/telegram",
+			MaxMessageParts: 3,
+		},
+		Slack: SlackConfig{
+			EventsPath: "/slack",
+		},
+		Github: GithubConfig{
+			WebhookPath: "/github",
+		},
+		Gitea: GiteaConfig{
+			WebhookPath: "/gitea",
+		},
+		Attachments: AttachmentsConfig{
+			Path:    "/files",
+			MaxSize: 20 << 20,
+		},
+		Retry: RetryConfig{
+			MaxAttempts:    5,
+			InitialBackoff: time.Second,
+			MaxBackoff:     time.Minute,
+		},
+		Queue: QueueConfig{
+			DedupRetention: 72 * time.Hour,
+		},
+	}
+
+	if path != "" {
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read config file: %v", err)
+		}
+
+		err = yaml.UnmarshalStrict(buf, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse config file %s: %v", path, err)
+		}
+	}
+
+	var errs ValidationError
+	errs = append(errs, cfg.applyEnv()...)
+	errs = append(errs, cfg.validate()...)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return cfg, nil
+}
+
+func (cfg *Config) applyEnv() []string {
+	var errs []string
+
+	envString("DB_PATH", &cfg.DBPath)
+	envString("TOKEN_ENCRYPTION_KEY", &cfg.TokenEncryptionKey)
+	envString("MESSENGER", &cfg.Messenger)
+	envString("TRACKER", &cfg.Tracker)
+	envString("TELEGRAM_TOKEN", &cfg.Telegram.Token)
+	envString("TELEGRAM_MODE", &cfg.Telegram.Mode)
+	envString("TELEGRAM_WEBHOOK_URL", &cfg.Telegram.WebhookURL)
+	envString("TELEGRAM_WEBHOOK_PATH", &cfg.Telegram.WebhookPath)
+	envString("TELEGRAM_WEBHOOK_SECRET", &cfg.Telegram.WebhookSecret)
+	envString("SLACK_TOKEN", &cfg.Slack.Token)
+	envString("SLACK_SIGNING_SECRET", &cfg.Slack.SigningSecret)
+	envString("SLACK_EVENTS_PATH", &cfg.Slack.EventsPath)
+	envString("GITHUB_TOKEN", &cfg.Github.Token)
+	envString("GITHUB_WEBHOOK_SECRET", &cfg.Github.WebhookSecret)
+	envString("GITHUB_WEBHOOK_PATH", &cfg.Github.WebhookPath)
+	envString("GITHUB_OAUTH_CLIENT_ID", &cfg.Github.OAuthClientID)
+	envString("GITHUB_APP_PRIVATE_KEY_PATH", &cfg.Github.AppPrivateKeyPath)
+	envString("GITEA_URL", &cfg.Gitea.URL)
+	envString("GITEA_TOKEN", &cfg.Gitea.Token)
+	envString("GITEA_WEBHOOK_SECRET", &cfg.Gitea.WebhookSecret)
+	envString("GITEA_WEBHOOK_PATH", &cfg.Gitea.WebhookPath)
+	envString("ATTACHMENTS_BACKEND", &cfg.Attachments.Backend)
+	envString("ATTACHMENTS_DIR", &cfg.Attachments.Dir)
+	envString("ATTACHMENTS_URL", &cfg.Attachments.URL)
+	envString("ATTACHMENTS_PATH", &cfg.Attachments.Path)
+
+	if v, ok := os.LookupEnv("PORT"); ok && v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("PORT: expected integer, got %q", v))
+		} else {
+			cfg.Port = port
+		}
+	}
+
+	if v, ok := os.LookupEnv("GITHUB_APP_ID"); ok && v != "" {
+		appID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("GITHUB_APP_ID: expected integer, got %q", v))
+		} else {
+			cfg.Github.AppID = appID
+		}
+	}
+
+	if v, ok := os.LookupEnv("GITHUB_APP_INSTALLATION_ID"); ok && v != "" {
+		installationID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("GITHUB_APP_INSTALLATION_ID: expected integer, got %q", v))
+		} else {
+			cfg.Github.AppInstallationID = installationID
+		}
+	}
+
+	if v, ok := os.LookupEnv("TELEGRAM_CHAT_ID"); ok && v != "" {
+		chatID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("TELEGRAM_CHAT_ID: expected integer, got %q", v))
+		} else {
+			cfg.Telegram.ChatID = chatID
+		}
+	}
+
+	return errs
+}
+
+func (cfg *Config) validate() []string {
+	var errs []string
+
+	if cfg.Port <= 0 || cfg.Port > 65535 {
+		errs = append(errs, fmt.Sprintf("port: expected value in 1..65535, got %d", cfg.Port))
+	}
+	if cfg.DBPath == "" {
+		errs = append(errs, "db_path: missing value")
+	}
+	if cfg.TokenEncryptionKey != "" {
+		if key, err := base64.StdEncoding.DecodeString(cfg.TokenEncryptionKey); err != nil || len(key) != 32 {
+			errs = append(errs, "token_encryption_key: expected base64 encoded 32 bytes")
+		}
+	}
+	switch cfg.Messenger {
+	case MessengerTelegram:
+		errs = append(errs, cfg.validateTelegram()...)
+	case MessengerSlack:
+		errs = append(errs, cfg.validateSlack()...)
+	default:
+		errs = append(errs, fmt.Sprintf("messenger: expected telegram or slack, got %q", cfg.Messenger))
+	}
+	if cfg.Telegram.MaxMessageParts < 1 {
+		errs = append(errs, fmt.Sprintf("telegram.max_message_parts: expected positive value, got %d", cfg.Telegram.MaxMessageParts))
+	}
+	switch cfg.Tracker {
+	case TrackerGithub:
+		errs = append(errs, cfg.validateGithub()...)
+	case TrackerGitea:
+		errs = append(errs, cfg.validateGitea()...)
+	default:
+		errs = append(errs, fmt.Sprintf("tracker: expected github or gitea, got %q", cfg.Tracker))
+	}
+
+	switch cfg.Attachments.Backend {
+	case "":
+	case AttachmentsBackendLocal:
+		if cfg.Attachments.Dir == "" {
+			errs = append(errs, "attachments.dir: missing value for local backend")
+		}
+		if !strings.HasPrefix(cfg.Attachments.URL, "http://") && !strings.HasPrefix(cfg.Attachments.URL, "https://") {
+			errs = append(errs, fmt.Sprintf("attachments.url: expected http:// or https:// URL, got %q", cfg.Attachments.URL))
+		}
+		if !strings.HasPrefix(cfg.Attachments.Path, "/") || cfg.Attachments.Path == "/" {
+			errs = append(errs, fmt.Sprintf("attachments.path: expected path starting with /, got %q", cfg.Attachments.Path))
+		} else if cfg.Attachments.Path == cfg.TrackerWebhookPath() || cfg.Attachments.Path == cfg.messengerWebhookPath() {
+			errs = append(errs, "attachments.path: must differ from webhook paths")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("attachments.backend: expected local or empty, got %q", cfg.Attachments.Backend))
+	}
+	if cfg.Attachments.MaxSize <= 0 {
+		errs = append(errs, fmt.Sprintf("attachments.max_size: expected positive value, got %d", cfg.Attachments.MaxSize))
+	}
+
+	if cfg.Retry.MaxAttempts < 1 {
+		errs = append(errs, fmt.Sprintf("retry.max_attempts: expected positive value, got %d", cfg.Retry.MaxAttempts))
+	}
+	if cfg.Retry.InitialBackoff <= 0 || cfg.Retry.MaxBackoff < cfg.Retry.InitialBackoff {
+		errs = append(errs, fmt.Sprintf(
+			"retry: expected 0 < initial_backoff <= max_backoff, got %v and %v",
+			cfg.Retry.InitialBackoff, cfg.Retry.MaxBackoff,
+		))
+	}
+
+	if cfg.Queue.DedupRetention <= 0 {
+		errs = append(errs, fmt.Sprintf("queue.dedup_retention: expected positive value, got %v", cfg.Queue.DedupRetention))
+	}
+
+	errs = append(errs, ValidateRoutes(cfg.Routes)...)
+	errs = append(errs, ValidatePermissions(cfg.Permissions)...)
+	errs = append(errs, ValidateFilters(cfg.Filters)...)
+	errs = append(errs, ValidateTemplateSets(cfg.Templates)...)
+
+	return errs
+}
+
+func (cfg *Config) validateTelegram() []string {
+	var errs []string
+
+	if cfg.Telegram.Token == "" {
+		errs = append(errs, "telegram.token: missing value")
+	}
+	if cfg.Telegram.ChatID == 0 && len(cfg.Routes) == 0 {
+		errs = append(errs, "telegram.chat_id: missing value, required when no routes are configured")
+	}
+	switch cfg.Telegram.Mode {
+	case TelegramModePolling:
+	case TelegramModeWebhook:
+		if !strings.HasPrefix(cfg.Telegram.WebhookURL, "https://") {
+			errs = append(errs, fmt.Sprintf("telegram.webhook_url: expected https:// URL in webhook mode, got %q", cfg.Telegram.WebhookURL))
+		}
+		if !strings.HasPrefix(cfg.Telegram.WebhookPath, "/") {
+			errs = append(errs, fmt.Sprintf("telegram.webhook_path: expected path starting with /, got %q", cfg.Telegram.WebhookPath))
+		} else if cfg.Telegram.WebhookPath == cfg.TrackerWebhookPath() {
+			errs = append(errs, "telegram.webhook_path: must differ from tracker webhook path")
+		}
+		if !telegramSecretRe.MatchString(cfg.Telegram.WebhookSecret) {
+			errs = append(errs, "telegram.webhook_secret: expected 1-256 characters A-Z, a-z, 0-9, _ and - in webhook mode")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("telegram.mode: expected polling or webhook, got %q", cfg.Telegram.Mode))
+	}
+
+	return errs
+}
+
+func (cfg *Config) validateSlack() []string {
+	var errs []string
+
+	if cfg.Slack.Token == "" {
+		errs = append(errs, "slack.token: missing value")
+	}
+	if cfg.Slack.SigningSecret == "" {
+		errs = append(errs, "slack.signing_secret: missing value")
+	}
+	if !strings.HasPrefix(cfg.Slack.EventsPath, "/") {
+		errs = append(errs, fmt.Sprintf("slack.events_path: expected path starting with /, got %q", cfg.Slack.EventsPath))
+	} else if cfg.Slack.EventsPath == cfg.TrackerWebhookPath() {
+		errs = append(errs, "slack.events_path: must differ from tracker webhook path")
+	}
+	if len(cfg.Slack.Channels) == 0 {
+		errs = append(errs, "slack.channels: missing value")
+	}
+
+	var channels []string
+	for channel := range cfg.Slack.Channels {
+		channels = append(channels, channel)
+	}
+	// Sorted, so that errors are reported in the same order every time
+	sort.Strings(channels)
+
+	chats := make(map[int64]string)
+	for _, channel := range channels {
+		chatID := cfg.Slack.Channels[channel]
+		if chatID <= 0 {
+			errs = append(errs, fmt.Sprintf("slack.channels: expected positive number for %s, got %d", channel, chatID))
+		} else if other, ok := chats[chatID]; ok {
+			errs = append(errs, fmt.Sprintf("slack.channels: number %d is used for both %s and %s", chatID, other, channel))
+		}
+		chats[chatID] = channel
+	}
+	if cfg.Slack.ChatID == 0 && len(cfg.Routes) == 0 {
+		errs = append(errs, "slack.chat_id: missing value, required when no routes are configured")
+	} else if _, ok := chats[cfg.Slack.ChatID]; cfg.Slack.ChatID != 0 && !ok {
+		errs = append(errs, fmt.Sprintf("slack.chat_id: expected one of channel numbers, got %d", cfg.Slack.ChatID))
+	}
+	for i, route := range cfg.Routes {
+		for _, chatID := range route.Chats {
+			if _, ok := chats[chatID]; !ok {
+				errs = append(errs, fmt.Sprintf("routes[%d].chats: expected one of slack channel numbers, got %d", i, chatID))
+			}
+		}
+	}
+
+	return errs
+}
+
+func (cfg *Config) validateGithub() []string {
+	var errs []string
+
+	switch {
+	case cfg.Github.AppID < 0:
+		errs = append(errs, fmt.Sprintf("github.app_id: expected positive value, got %d", cfg.Github.AppID))
+	case cfg.Github.AppID > 0:
+		if cfg.Github.AppPrivateKeyPath == "" {
+			errs = append(errs, "github.app_private_key_path: missing value, required with app_id")
+		}
+		if cfg.Github.AppInstallationID < 0 {
+			errs = append(errs, fmt.Sprintf("github.app_installation_id: expected positive value, got %d", cfg.Github.AppInstallationID))
+		}
+	case cfg.Github.Token == "":
+		errs = append(errs, "github.token: missing value, required when app_id is not set")
+	}
+	if cfg.Github.WebhookSecret == "" {
+		errs = append(errs, "github.webhook_secret: missing value")
+	}
+	if !strings.HasPrefix(cfg.Github.WebhookPath, "/") {
+		errs = append(errs, fmt.Sprintf("github.webhook_path: expected path starting with /, got %q", cfg.Github.WebhookPath))
+	}
+
+	return errs
+}
+
+func (cfg *Config) validateGitea() []string {
+	var errs []string
+
+	if !strings.HasPrefix(cfg.Gitea.URL, "http://") && !strings.HasPrefix(cfg.Gitea.URL, "https://") {
+		errs = append(errs, fmt.Sprintf("gitea.url: expected http:// or https:// URL, got %q", cfg.Gitea.URL))
+	}
+	if cfg.Gitea.Token == "" {
+		errs = append(errs, "gitea.token: missing value")
+	}
+	if cfg.Gitea.WebhookSecret == "" {
+		errs = append(errs, "gitea.webhook_secret: missing value")
+	}
+	if !strings.HasPrefix(cfg.Gitea.WebhookPath, "/") {
+		errs = append(errs, fmt.Sprintf("gitea.webhook_path: expected path starting with /, got %q", cfg.Gitea.WebhookPath))
+	}
+
+	return errs
+}
+
+// TrackerWebhookPath - returns path tracker sends events to
+func (cfg *Config) TrackerWebhookPath() string {
+	if cfg.Tracker == TrackerGitea {
+		return cfg.Gitea.WebhookPath
+	}
+
+	return cfg.Github.WebhookPath
+}
+
+// TokenKey - returns decoded key to encrypt linked tracker tokens with, nil if tokens are stored in plaintext
+func (cfg *Config) TokenKey() []byte {
+	key, err := base64.StdEncoding.DecodeString(cfg.TokenEncryptionKey)
+	if err != nil || len(key) == 0 {
+		return nil
+	}
+
+	return key
+}
+
+// DefaultChatID - returns chat to bridge events not matching any route to, 0 if there is none
+func (cfg *Config) DefaultChatID() int64 {
+	if cfg.Messenger == MessengerSlack {
+		return cfg.Slack.ChatID
+	}
+
+	return cfg.Telegram.ChatID
+}
+
+// messengerWebhookPath - returns path messenger sends updates to, empty if it is polled
+func (cfg *Config) messengerWebhookPath() string {
+	switch {
+	case cfg.Messenger == MessengerSlack:
+		return cfg.Slack.EventsPath
+	case cfg.Telegram.Mode == TelegramModeWebhook:
+		return cfg.Telegram.WebhookPath
+	}
+
+	return ""
+}
+
+// ValidateRoutes - returns problems found in routes
+func ValidateRoutes(routes []Route) []string {
+	var errs []string
+
+	for i, route := range routes {
+		if len(route.Repos) == 0 {
+			errs = append(errs, fmt.Sprintf("routes[%d].repos: missing value", i))
+		}
+		for _, pattern := range route.Repos {
+			if strings.Count(pattern, "/") != 1 {
+				errs = append(errs, fmt.Sprintf("routes[%d].repos: expected owner/repo pattern, got %q", i, pattern))
+				continue
+			}
+			if _, err := path.Match(pattern, ""); err != nil {
+				errs = append(errs, fmt.Sprintf("routes[%d].repos: invalid pattern %q: %v", i, pattern, err))
+			}
+		}
+		if len(route.Chats) == 0 {
+			errs = append(errs, fmt.Sprintf("routes[%d].chats: missing value", i))
+		}
+		switch route.Mode {
+		case "", RouteModeRealtime:
+			if route.Schedule != "" {
+				errs = append(errs, fmt.Sprintf("routes[%d].schedule: only used in digest and both modes", i))
+			}
+		case RouteModeDigest, RouteModeBoth:
+			if !scheduleRe.MatchString(route.Schedule) {
+				errs = append(errs, fmt.Sprintf("routes[%d].schedule: expected hourly or daily HH:MM, got %q", i, route.Schedule))
+			}
+		default:
+			errs = append(errs, fmt.Sprintf("routes[%d].mode: expected realtime, digest or both, got %q", i, route.Mode))
+		}
+	}
+
+	return errs
+}
+
+// ValidatePermissions - returns problems found in permission grants
+func ValidatePermissions(grants []Grant) []string {
+	var errs []string
+
+	for i, grant := range grants {
+		if len(grant.Users) == 0 {
+			errs = append(errs, fmt.Sprintf("permissions[%d].users: missing value", i))
+		}
+		for _, user := range grant.Users {
+			if !grantUserRe.MatchString(user) {
+				errs = append(errs, fmt.Sprintf("permissions[%d].users: expected numeric Telegram user ID or Slack member ID, got %q", i, user))
+			}
+		}
+		switch grant.Role {
+		case RoleViewer, RoleStaff, RoleMaintainer:
+		default:
+			errs = append(errs, fmt.Sprintf("permissions[%d].role: expected viewer, staff or maintainer, got %q", i, grant.Role))
+		}
+		for _, pattern := range grant.Repos {
+			if strings.Count(pattern, "/") != 1 {
+				errs = append(errs, fmt.Sprintf("permissions[%d].repos: expected owner/repo pattern, got %q", i, pattern))
+				continue
+			}
+			if _, err := path.Match(pattern, ""); err != nil {
+				errs = append(errs, fmt.Sprintf("permissions[%d].repos: invalid pattern %q: %v", i, pattern, err))
+			}
+		}
+	}
+
+	return errs
+}
+
+// ValidateFilters - returns problems found in filter rules
+func ValidateFilters(rules []FilterRule) []string {
+	var errs []string
+
+	for i, rule := range rules {
+		for _, pattern := range rule.Repos {
+			if strings.Count(pattern, "/") != 1 {
+				errs = append(errs, fmt.Sprintf("filters[%d].repos: expected owner/repo pattern, got %q", i, pattern))
+				continue
+			}
+			if _, err := path.Match(pattern, ""); err != nil {
+				errs = append(errs, fmt.Sprintf("filters[%d].repos: invalid pattern %q: %v", i, pattern, err))
+			}
+		}
+		if _, err := regexp.Compile(rule.Body); err != nil {
+			errs = append(errs, fmt.Sprintf("filters[%d].body: invalid regular expression: %v", i, err))
+		}
+		for _, state := range rule.States {
+			if state != "open" && state != "closed" {
+				errs = append(errs, fmt.Sprintf("filters[%d].states: expected open or closed, got %q", i, state))
+			}
+		}
+
+		switch rule.Outcome {
+		case FilterAllow, FilterDeny:
+			if len(rule.Replace) > 0 {
+				errs = append(errs, fmt.Sprintf("filters[%d].replace: only used with transform outcome", i))
+			}
+		case FilterTransform:
+			if len(rule.Replace) == 0 {
+				errs = append(errs, fmt.Sprintf("filters[%d].replace: missing value for transform outcome", i))
+			}
+		default:
+			errs = append(errs, fmt.Sprintf("filters[%d].outcome: expected allow, deny or transform, got %q", i, rule.Outcome))
+		}
+		for j, r := range rule.Replace {
+			if _, err := regexp.Compile(r.Pattern); err != nil || r.Pattern == "" {
+				errs = append(errs, fmt.Sprintf("filters[%d].replace[%d].pattern: expected regular expression, got %q", i, j, r.Pattern))
+			}
+		}
+	}
+
+	return errs
+}
+
+// ValidateTemplateSets - returns problems found in template sets,
+// templates themselves are parsed by templates package
+func ValidateTemplateSets(sets []TemplateSet) []string {
+	var errs []string
+
+	for i, set := range sets {
+		if len(set.Messages) == 0 {
+			errs = append(errs, fmt.Sprintf("templates[%d].messages: missing value", i))
+		}
+		for _, pattern := range set.Repos {
+			if strings.Count(pattern, "/") != 1 {
+				errs = append(errs, fmt.Sprintf("templates[%d].repos: expected owner/repo pattern, got %q", i, pattern))
+				continue
+			}
+			if _, err := path.Match(pattern, ""); err != nil {
+				errs = append(errs, fmt.Sprintf("templates[%d].repos: invalid pattern %q: %v", i, pattern, err))
+			}
+		}
+	}
+
+	return errs
+}
+
+func envString(name string, dst *string) {
+	if v, ok := os.LookupEnv(name); ok && v != "" {
+		*dst = v
+	}
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+// clearEnv - unsets env overrides for the duration of test, so that only config file is checked
+func clearEnv(t *testing.T, names ...string) func() {
+	t.Helper()
+
+	saved := map[string]string{}
+	for _, name := range names {
+		if v, ok := os.LookupEnv(name); ok {
+			saved[name] = v
+		}
+		os.Unsetenv(name)
+	}
+
+	return func() {
+		for _, name := range names {
+			os.Unsetenv(name)
+			if v, ok := saved[name]; ok {
+				os.Setenv(name, v)
+			}
+		}
+	}
+}
+
+func writeConfig(t *testing.T, data string) string {
+	t.Helper()
+
+	f, err := ioutil.TempFile("", "config-*.yml")
+	if err != nil {
+		t.Fatalf("unable to create config file: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(data); err != nil {
+		t.Fatalf("unable to write config file: %v", err)
+	}
+
+	return f.Name()
+}
+
+var envNames = []string{
+	"PORT", "DB_PATH", "TOKEN_ENCRYPTION_KEY", "MESSENGER", "TRACKER",
+	"TELEGRAM_TOKEN", "TELEGRAM_CHAT_ID", "TELEGRAM_MODE",
+	"TELEGRAM_WEBHOOK_URL", "TELEGRAM_WEBHOOK_PATH", "TELEGRAM_WEBHOOK_SECRET",
+	"SLACK_TOKEN", "SLACK_SIGNING_SECRET", "SLACK_EVENTS_PATH",
+	"GITHUB_TOKEN", "GITHUB_WEBHOOK_SECRET", "GITHUB_WEBHOOK_PATH", "GITHUB_OAUTH_CLIENT_ID",
+	"GITHUB_APP_ID", "GITHUB_APP_PRIVATE_KEY_PATH", "GITHUB_APP_INSTALLATION_ID",
+	"GITEA_URL", "GITEA_TOKEN", "GITEA_WEBHOOK_SECRET", "GITEA_WEBHOOK_PATH",
+	"ATTACHMENTS_BACKEND", "ATTACHMENTS_DIR", "ATTACHMENTS_URL", "ATTACHMENTS_PATH",
+}
+
+func TestLoadValid(t *testing.T) {
+	defer clearEnv(t, envNames...)()
+
+	path := writeConfig(t, `
+port: 8080
+db_path: bridge.db
+telegram:
+  token: telegram-token
+  chat_id: -100123
+github:
+  token: github-token
+  webhook_secret: secret
+routes:
+  - repos: [andreyst/*]
+    chats: [-100456]
+`)
+	defer os.Remove(path)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Telegram.Mode != TelegramModePolling || cfg.Github.WebhookPath != "/github" {
+		t.Errorf("Load() did not apply defaults, got mode %q and webhook path %q", cfg.Telegram.Mode, cfg.Github.WebhookPath)
+	}
+	if len(cfg.Routes) != 1 || cfg.Routes[0].Chats[0] != -100456 {
+		t.Errorf("Load() routes = %+v", cfg.Routes)
+	}
+}
+
+func TestLoadEnvOverrides(t *testing.T) {
+	defer clearEnv(t, envNames...)()
+
+	path := writeConfig(t, `
+port: 8080
+db_path: bridge.db
+telegram:
+  token: telegram-token
+  chat_id: -100123
+github:
+  token: github-token
+  webhook_secret: secret
+`)
+	defer os.Remove(path)
+
+	os.Setenv("PORT", "9090")
+	os.Setenv("GITHUB_TOKEN", "env-token")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Port != 9090 || cfg.Github.Token != "env-token" {
+		t.Errorf("Load() did not apply env overrides, got port %d and token %q", cfg.Port, cfg.Github.Token)
+	}
+}
+
+func TestLoadReportsAllProblems(t *testing.T) {
+	defer clearEnv(t, envNames...)()
+
+	path := writeConfig(t, `
+port: 70000
+token_encryption_key: c2hvcnQ=
+telegram:
+  mode: push
+github:
+  token: github-token
+retry:
+  max_attempts: 0
+routes:
+  - repos: [andreyst]
+    chats: [-100456]
+    mode: digest
+permissions:
+  - users: ["@andreyst"]
+    role: admin
+`)
+	defer os.Remove(path)
+
+	os.Setenv("TELEGRAM_CHAT_ID", "not-a-number")
+
+	_, err := Load(path)
+	verr, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("Load() error = %v, want ValidationError", err)
+	}
+
+	want := []string{
+		`TELEGRAM_CHAT_ID: expected integer, got "not-a-number"`,
+		"port: expected value in 1..65535, got 70000",
+		"db_path: missing value",
+		"token_encryption_key: expected base64 encoded 32 bytes",
+		"telegram.token: missing value",
+		`telegram.mode: expected polling or webhook, got "push"`,
+		"github.webhook_secret: missing value",
+		"retry.max_attempts: expected positive value, got 0",
+		`routes[0].repos: expected owner/repo pattern, got "andreyst"`,
+		`routes[0].schedule: expected hourly or daily HH:MM, got ""`,
+		`permissions[0].users: expected numeric Telegram user ID or Slack member ID, got "@andreyst"`,
+		`permissions[0].role: expected viewer, staff or maintainer, got "admin"`,
+	}
+	for _, problem := range want {
+		found := false
+		for _, got := range verr {
+			if got == problem {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("Load() error does not report %q, got:\n  %s", problem, strings.Join(verr, "\n  "))
+		}
+	}
+}
+
+func TestLoadUnknownKey(t *testing.T) {
+	defer clearEnv(t, envNames...)()
+
+	path := writeConfig(t, `
+db_path: bridge.db
+telegram:
+  tokn: telegram-token
+`)
+	defer os.Remove(path)
+
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), "tokn") {
+		t.Errorf("Load() error = %v, want error about unknown key", err)
+	}
+}
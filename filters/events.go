@@ -0,0 +1,98 @@
+package filters
+
+import (
+	"github.com/andreyst/tracker-messenger-bridge/routing"
+	"github.com/andreyst/tracker-messenger-bridge/tracker"
+)
+
+// fields - values of tracker event rules are matched against
+type fields struct {
+	event  string
+	repo   string
+	action string
+	sender string
+	labels []string
+	state  string
+	body   string
+}
+
+// extract - returns fields of tracker event, false for other events, e.g. messenger updates
+func extract(event interface{}) (fields, bool) {
+	switch p := event.(type) {
+	case tracker.IssueEvent:
+		return fields{
+			event:  routing.EventIssues,
+			repo:   p.Repo.FullName,
+			action: p.Action,
+			sender: p.Sender.Login,
+			labels: p.Issue.Labels,
+			state:  p.Issue.State,
+			body:   p.Issue.Body,
+		}, true
+	case tracker.CommentEvent:
+		return fields{
+			event:  routing.EventIssueComment,
+			repo:   p.Repo.FullName,
+			action: p.Action,
+			sender: p.Sender.Login,
+			labels: p.Issue.Labels,
+			state:  p.Issue.State,
+			body:   p.Comment.Body,
+		}, true
+	case tracker.PullRequestEvent:
+		return fields{
+			event:  routing.EventPullRequest,
+			repo:   p.Repo.FullName,
+			action: p.Action,
+			sender: p.Sender.Login,
+			labels: p.PullRequest.Labels,
+			state:  p.PullRequest.State,
+			body:   p.PullRequest.Body,
+		}, true
+	case tracker.ReviewEvent:
+		return fields{
+			event:  routing.EventPullRequestReview,
+			repo:   p.Repo.FullName,
+			action: p.Action,
+			sender: p.Sender.Login,
+			labels: p.PullRequest.Labels,
+			state:  p.PullRequest.State,
+			body:   p.Review.Body,
+		}, true
+	case tracker.ReviewCommentEvent:
+		return fields{
+			event:  routing.EventPullRequestReviewComment,
+			repo:   p.Repo.FullName,
+			action: p.Action,
+			sender: p.Sender.Login,
+			labels: p.PullRequest.Labels,
+			state:  p.PullRequest.State,
+			body:   p.Comment.Body,
+		}, true
+	}
+
+	return fields{}, false
+}
+
+// withBody - returns copy of tracker event with body of issue, pull request, comment or review replaced
+func withBody(event interface{}, body string) interface{} {
+	switch p := event.(type) {
+	case tracker.IssueEvent:
+		p.Issue.Body = body
+		return p
+	case tracker.CommentEvent:
+		p.Comment.Body = body
+		return p
+	case tracker.PullRequestEvent:
+		p.PullRequest.Body = body
+		return p
+	case tracker.ReviewEvent:
+		p.Review.Body = body
+		return p
+	case tracker.ReviewCommentEvent:
+		p.Comment.Body = body
+		return p
+	}
+
+	return event
+}
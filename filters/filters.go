@@ -0,0 +1,186 @@
+package filters
+
+import (
+	"errors"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/andreyst/tracker-messenger-bridge/config"
+)
+
+// Engine - decides which events are bridged with filter rules
+// Rules can be replaced at runtime with SetRules or Replace
+type Engine struct {
+	mutex sync.RWMutex
+	rules []rule
+}
+
+type rule struct {
+	config.FilterRule
+	senders []*regexp.Regexp
+	body    *regexp.Regexp
+	replace []replacement
+}
+
+type replacement struct {
+	pattern *regexp.Regexp
+	with    string
+}
+
+// Decision - result of checking event against rules
+type Decision struct {
+	// Event to pass to handlers, transformed by rules
+	Event interface{}
+	// Whether event is bridged
+	Allowed bool
+	// Index of rule which allowed or denied event, -1 when no rule did
+	Rule int
+}
+
+// NewEngine - creates engine with rules
+func NewEngine(rules []config.FilterRule) (*Engine, error) {
+	e := &Engine{}
+
+	err := e.SetRules(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// SetRules - validates and replaces rules
+func (e *Engine) SetRules(rules []config.FilterRule) error {
+	if errs := config.ValidateFilters(rules); len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	compiled := make([]rule, len(rules))
+	for i, r := range rules {
+		compiled[i] = rule{FilterRule: r}
+		for _, sender := range r.Senders {
+			compiled[i].senders = append(compiled[i].senders, globRegexp(sender))
+		}
+		if r.Body != "" {
+			compiled[i].body = regexp.MustCompile(r.Body)
+		}
+		for _, rep := range r.Replace {
+			compiled[i].replace = append(compiled[i].replace, replacement{pattern: regexp.MustCompile(rep.Pattern), with: rep.With})
+		}
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.rules = compiled
+
+	return nil
+}
+
+// Replace - replaces rules with those of other engine, e.g. built from reloaded config
+func (e *Engine) Replace(other *Engine) {
+	other.mutex.RLock()
+	rules := other.rules
+	other.mutex.RUnlock()
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.rules = rules
+}
+
+// Apply - checks event against rules in order until one allows or denies it,
+// events which are not Github events or are not matched by any rule are allowed
+func (e *Engine) Apply(event interface{}) Decision {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	f, ok := extract(event)
+	if !ok {
+		return Decision{Event: event, Allowed: true, Rule: -1}
+	}
+
+	for i, r := range e.rules {
+		if !r.matches(f) {
+			continue
+		}
+
+		switch r.Outcome {
+		case config.FilterAllow:
+			return Decision{Event: event, Allowed: true, Rule: i}
+		case config.FilterDeny:
+			return Decision{Event: event, Allowed: false, Rule: i}
+		case config.FilterTransform:
+			for _, rep := range r.replace {
+				f.body = rep.pattern.ReplaceAllString(f.body, rep.with)
+			}
+			event = withBody(event, f.body)
+		}
+	}
+
+	return Decision{Event: event, Allowed: true, Rule: -1}
+}
+
+func (r rule) matches(f fields) bool {
+	if len(r.Repos) > 0 && !matchAny(r.Repos, func(pattern string) bool {
+		ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(f.repo))
+		return ok
+	}) {
+		return false
+	}
+	if len(r.Events) > 0 && !contains(r.Events, f.event) {
+		return false
+	}
+	if len(r.Actions) > 0 && !contains(r.Actions, f.action) {
+		return false
+	}
+	if len(r.senders) > 0 {
+		matched := false
+		for _, sender := range r.senders {
+			if sender.MatchString(f.sender) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(r.Labels) > 0 && !matchAny(r.Labels, func(label string) bool {
+		return contains(f.labels, label)
+	}) {
+		return false
+	}
+	if len(r.States) > 0 && !contains(r.States, f.state) {
+		return false
+	}
+	if r.body != nil && !r.body.MatchString(f.body) {
+		return false
+	}
+
+	return true
+}
+
+// globRegexp - compiles case-insensitive pattern where * matches any characters and other characters match themselves
+func globRegexp(pattern string) *regexp.Regexp {
+	quoted := strings.Replace(regexp.QuoteMeta(pattern), `\*`, ".*", -1)
+	return regexp.MustCompile("(?i)^" + quoted + "$")
+}
+
+func matchAny(values []string, match func(string) bool) bool {
+	for _, v := range values {
+		if match(v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func contains(values []string, value string) bool {
+	return matchAny(values, func(v string) bool {
+		return strings.EqualFold(v, value)
+	})
+}
@@ -0,0 +1,308 @@
+package filters
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/andreyst/tracker-messenger-bridge/config"
+	"github.com/andreyst/tracker-messenger-bridge/tracker"
+	trackergithub "github.com/andreyst/tracker-messenger-bridge/tracker/github"
+	"gopkg.in/go-playground/webhooks.v5/github"
+)
+
+func issuesPayload(t *testing.T, sender string, labels []string, state string, body string) tracker.IssueEvent {
+	t.Helper()
+
+	var p github.IssuesPayload
+	decodePayload(t, map[string]interface{}{
+		"action":     "opened",
+		"issue":      map[string]interface{}{"state": state, "body": body, "labels": labelObjects(labels)},
+		"repository": map[string]interface{}{"full_name": "andreyst/tracker-messenger-bridge"},
+		"sender":     map[string]interface{}{"login": sender},
+	}, &p)
+
+	return trackergithub.IssueEvent(p)
+}
+
+func commentPayload(t *testing.T, sender string, body string) tracker.CommentEvent {
+	t.Helper()
+
+	var p github.IssueCommentPayload
+	decodePayload(t, map[string]interface{}{
+		"action":     "created",
+		"issue":      map[string]interface{}{"state": "open"},
+		"comment":    map[string]interface{}{"body": body},
+		"repository": map[string]interface{}{"full_name": "andreyst/tracker-messenger-bridge"},
+		"sender":     map[string]interface{}{"login": sender},
+	}, &p)
+
+	return trackergithub.CommentEvent(p)
+}
+
+func labelObjects(labels []string) []map[string]string {
+	objects := []map[string]string{}
+	for _, label := range labels {
+		objects = append(objects, map[string]string{"name": label})
+	}
+
+	return objects
+}
+
+// decodePayload - fills Github payload type the way webhook parser does, from JSON
+func decodePayload(t *testing.T, data interface{}, payload interface{}) {
+	t.Helper()
+
+	buf, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("unable to marshal payload: %v", err)
+	}
+	if err := json.Unmarshal(buf, payload); err != nil {
+		t.Fatalf("unable to parse payload: %v", err)
+	}
+}
+
+func pullRequestPayload(t *testing.T, data string) tracker.PullRequestEvent {
+	t.Helper()
+
+	var p github.PullRequestPayload
+	if err := json.Unmarshal([]byte(data), &p); err != nil {
+		t.Fatalf("unable to parse pull request payload: %v", err)
+	}
+
+	return trackergithub.PullRequestEvent(p)
+}
+
+func newEngine(t *testing.T, rules []config.FilterRule) *Engine {
+	t.Helper()
+
+	e, err := NewEngine(rules)
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+
+	return e
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name        string
+		rules       []config.FilterRule
+		event       interface{}
+		wantAllowed bool
+		wantRule    int
+	}{
+		{
+			name:        "no rules",
+			event:       issuesPayload(t, "andreyst", nil, "open", "body"),
+			wantAllowed: true,
+			wantRule:    -1,
+		},
+		{
+			name: "first matching deny wins",
+			rules: []config.FilterRule{
+				{Events: []string{"issues"}, Outcome: config.FilterDeny},
+				{Events: []string{"issues"}, Outcome: config.FilterAllow},
+			},
+			event:       issuesPayload(t, "andreyst", nil, "open", "body"),
+			wantAllowed: false,
+			wantRule:    0,
+		},
+		{
+			name: "first matching allow wins",
+			rules: []config.FilterRule{
+				{Events: []string{"issue_comment"}, Outcome: config.FilterDeny},
+				{Events: []string{"issues"}, Outcome: config.FilterAllow},
+				{Outcome: config.FilterDeny},
+			},
+			event:       issuesPayload(t, "andreyst", nil, "open", "body"),
+			wantAllowed: true,
+			wantRule:    1,
+		},
+		{
+			name: "sender glob",
+			rules: []config.FilterRule{
+				{Senders: []string{"*[bot]"}, Outcome: config.FilterDeny},
+			},
+			event:       commentPayload(t, "Dependabot[bot]", "bump"),
+			wantAllowed: false,
+			wantRule:    0,
+		},
+		{
+			name: "sender glob does not match other logins",
+			rules: []config.FilterRule{
+				{Senders: []string{"*[bot]"}, Outcome: config.FilterDeny},
+			},
+			event:       commentPayload(t, "botanist", "hello"),
+			wantAllowed: true,
+			wantRule:    -1,
+		},
+		{
+			name: "body regex",
+			rules: []config.FilterRule{
+				{Events: []string{"issue_comment"}, Body: "@andreyst/team", Outcome: config.FilterAllow},
+				{Events: []string{"issue_comment"}, Outcome: config.FilterDeny},
+			},
+			event:       commentPayload(t, "user", "cc @andreyst/team"),
+			wantAllowed: true,
+			wantRule:    0,
+		},
+		{
+			name: "body regex does not match",
+			rules: []config.FilterRule{
+				{Events: []string{"issue_comment"}, Body: "@andreyst/team", Outcome: config.FilterAllow},
+				{Events: []string{"issue_comment"}, Outcome: config.FilterDeny},
+			},
+			event:       commentPayload(t, "user", "+1"),
+			wantAllowed: false,
+			wantRule:    1,
+		},
+		{
+			name: "any of labels",
+			rules: []config.FilterRule{
+				{Labels: []string{"wontfix", "duplicate"}, Outcome: config.FilterDeny},
+			},
+			event:       issuesPayload(t, "user", []string{"bug", "Duplicate"}, "open", "body"),
+			wantAllowed: false,
+			wantRule:    0,
+		},
+		{
+			name: "labels do not match",
+			rules: []config.FilterRule{
+				{Labels: []string{"wontfix"}, Outcome: config.FilterDeny},
+			},
+			event:       issuesPayload(t, "user", []string{"bug"}, "open", "body"),
+			wantAllowed: true,
+			wantRule:    -1,
+		},
+		{
+			name: "state",
+			rules: []config.FilterRule{
+				{States: []string{"closed"}, Outcome: config.FilterDeny},
+			},
+			event:       issuesPayload(t, "user", nil, "closed", "body"),
+			wantAllowed: false,
+			wantRule:    0,
+		},
+		{
+			name: "all conditions have to match",
+			rules: []config.FilterRule{
+				{Repos: []string{"andreyst/*"}, Events: []string{"issues"}, Actions: []string{"closed"}, Outcome: config.FilterDeny},
+			},
+			event:       issuesPayload(t, "user", nil, "open", "body"),
+			wantAllowed: true,
+			wantRule:    -1,
+		},
+		{
+			name: "pull request payload",
+			rules: []config.FilterRule{
+				{Repos: []string{"AndreySt/*"}, Events: []string{"pull_request"}, Labels: []string{"wip"}, Outcome: config.FilterDeny},
+			},
+			event: pullRequestPayload(t, `{
+				"action": "opened",
+				"pull_request": {"state": "open", "body": "draft", "labels": [{"name": "wip"}]},
+				"repository": {"full_name": "andreyst/tracker-messenger-bridge"},
+				"sender": {"login": "user"}
+			}`),
+			wantAllowed: false,
+			wantRule:    0,
+		},
+		{
+			name: "messenger updates are not filtered",
+			rules: []config.FilterRule{
+				{Outcome: config.FilterDeny},
+			},
+			event:       "not a tracker event",
+			wantAllowed: true,
+			wantRule:    -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newEngine(t, tt.rules).Apply(tt.event)
+			if d.Allowed != tt.wantAllowed || d.Rule != tt.wantRule {
+				t.Errorf("Apply() = allowed %v by rule %d, want allowed %v by rule %d", d.Allowed, d.Rule, tt.wantAllowed, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestApplyTransform(t *testing.T) {
+	e := newEngine(t, []config.FilterRule{
+		{Events: []string{"issue_comment"}, Outcome: config.FilterTransform, Replace: []config.Replacement{
+			{Pattern: "(?s)<details>.*?</details>", With: ""},
+		}},
+		{Events: []string{"issue_comment"}, Outcome: config.FilterTransform, Replace: []config.Replacement{
+			{Pattern: `#(\d+)`, With: "issue $1"},
+		}},
+		// Matched against transformed body
+		{Events: []string{"issue_comment"}, Body: "secret", Outcome: config.FilterDeny},
+	})
+
+	d := e.Apply(commentPayload(t, "user", "see #12<details>secret\nlog</details>"))
+	if !d.Allowed || d.Rule != -1 {
+		t.Fatalf("Apply() = allowed %v by rule %d, want allowed by no rule", d.Allowed, d.Rule)
+	}
+	comment, ok := d.Event.(tracker.CommentEvent)
+	if !ok {
+		t.Fatalf("Apply() event = %T, want tracker.CommentEvent", d.Event)
+	}
+	if comment.Comment.Body != "see issue 12" {
+		t.Errorf("transformed body = %q, want %q", comment.Comment.Body, "see issue 12")
+	}
+}
+
+func TestApplyTransformPullRequest(t *testing.T) {
+	e := newEngine(t, []config.FilterRule{
+		{Events: []string{"pull_request"}, Outcome: config.FilterTransform, Replace: []config.Replacement{
+			{Pattern: "<!--.*?-->", With: ""},
+		}},
+	})
+
+	d := e.Apply(pullRequestPayload(t, `{
+		"action": "opened",
+		"pull_request": {"state": "open", "body": "<!-- template -->Fixes bug"},
+		"repository": {"full_name": "andreyst/tracker-messenger-bridge"},
+		"sender": {"login": "user"}
+	}`))
+	pr, ok := d.Event.(tracker.PullRequestEvent)
+	if !ok {
+		t.Fatalf("Apply() event = %T, want tracker.PullRequestEvent", d.Event)
+	}
+	if pr.PullRequest.Body != "Fixes bug" {
+		t.Errorf("transformed body = %q, want %q", pr.PullRequest.Body, "Fixes bug")
+	}
+}
+
+func TestSetRules(t *testing.T) {
+	e := newEngine(t, []config.FilterRule{
+		{Events: []string{"issues"}, Outcome: config.FilterDeny},
+	})
+	event := issuesPayload(t, "user", nil, "open", "body")
+
+	if d := e.Apply(event); d.Allowed {
+		t.Fatalf("Apply() before SetRules allowed event")
+	}
+
+	// Rules are replaced on SIGHUP
+	err := e.SetRules([]config.FilterRule{
+		{Events: []string{"issues"}, Outcome: config.FilterAllow},
+	})
+	if err != nil {
+		t.Fatalf("SetRules: %v", err)
+	}
+	if d := e.Apply(event); !d.Allowed || d.Rule != 0 {
+		t.Errorf("Apply() after SetRules = allowed %v by rule %d, want allowed by rule 0", d.Allowed, d.Rule)
+	}
+
+	// Invalid rules keep previous ones
+	err = e.SetRules([]config.FilterRule{
+		{Body: "(", Outcome: config.FilterDeny},
+	})
+	if err == nil {
+		t.Fatalf("SetRules with invalid body regex succeeded")
+	}
+	if d := e.Apply(event); !d.Allowed || d.Rule != 0 {
+		t.Errorf("Apply() after invalid SetRules = allowed %v by rule %d, want allowed by rule 0", d.Allowed, d.Rule)
+	}
+}
@@ -11,4 +11,5 @@ require (
 	github.com/technoweenie/multipartstreamer v1.0.1 // indirect
 	golang.org/x/oauth2 v0.0.0-20210201163806-010130855d6c
 	gopkg.in/go-playground/webhooks.v5 v5.17.0
+	gopkg.in/yaml.v2 v2.2.2
 )
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	bot "github.com/andreyst/tracker-messenger-bridge/bot"
+	"github.com/andreyst/tracker-messenger-bridge/commands"
+	"github.com/andreyst/tracker-messenger-bridge/messenger"
+)
+
+// CommandsEventHandler - runs messenger commands from registry and confirms them in chat
+// Should be added before handlers reacting to replies, so that commands are not bridged as comments
+type CommandsEventHandler struct {
+	Registry *commands.Registry
+}
+
+// Handle - handles update
+func (h CommandsEventHandler) Handle(ctx context.Context, b *bot.Bot, env bot.Envelope) (bool, error) {
+	update, ok := env.Payload.(messenger.Update)
+	if !ok {
+		return false, nil
+	}
+
+	if update.Message == nil {
+		return false, nil
+	}
+
+	cmd, reply, err := h.Registry.Execute(ctx, b, env, update.Message)
+	if cmd == nil {
+		return false, nil
+	}
+	if err != nil {
+		// Command is not retried, so that user is not confused by delayed effects
+		fmt.Printf("Error running /%s: %v\n", cmd.Name, err)
+		reply = fmt.Sprintf("Unable to run /%s: %v", cmd.Name, err)
+	}
+
+	_, err = b.Messenger.Send(ctx, messenger.Outgoing{
+		ChatID:  update.Message.ChatID,
+		Text:    reply,
+		ReplyTo: cmd.ReplyToMessageID,
+	})
+	if err != nil {
+		// Command already took effect, retrying would run it again, e.g. create issue twice
+		log.Printf("Error replying to /%s: %v\n", cmd.Name, err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"github.com/andreyst/tracker-messenger-bridge/bot"
+	trackergithub "github.com/andreyst/tracker-messenger-bridge/tracker/github"
+	"gopkg.in/go-playground/webhooks.v5/github"
+)
+
+// GithubInstallationEventHandler - keeps track of repositories Github App is installed to,
+// events are ignored when bridge is not running as an app
+type GithubInstallationEventHandler struct{}
+
+// Handle - handle event
+func (GithubInstallationEventHandler) Handle(ctx context.Context, b *bot.Bot, env bot.Envelope) (bool, error) {
+	client, ok := b.Tracker.(*trackergithub.Client)
+	if !ok || client.App() == nil {
+		return false, nil
+	}
+	app := client.App()
+
+	switch p := env.Payload.(type) {
+	case github.InstallationPayload:
+		log.Printf("Github App installation %d (%s) %s\n", p.Installation.ID, p.Installation.Account.Login, p.Action)
+
+		var repos []string
+		for _, repo := range p.Repositories {
+			repos = append(repos, repo.FullName)
+		}
+		switch p.Action {
+		case "created", "unsuspend":
+			app.AddRepos(p.Installation.ID, repos)
+		case "deleted", "suspend":
+			app.RemoveInstallation(p.Installation.ID)
+		}
+	case github.InstallationRepositoriesPayload:
+		log.Printf("Github App installation %d (%s) repositories %s\n", p.Installation.ID, p.Installation.Account.Login, p.Action)
+
+		var added, removed []string
+		for _, repo := range p.RepositoriesAdded {
+			added = append(added, repo.FullName)
+		}
+		for _, repo := range p.RepositoriesRemoved {
+			removed = append(removed, repo.FullName)
+		}
+		app.AddRepos(p.Installation.ID, added)
+		app.RemoveRepos(removed)
+	default:
+		return false, nil
+	}
+
+	return true, nil
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/andreyst/tracker-messenger-bridge/bot"
+	"github.com/andreyst/tracker-messenger-bridge/routing"
+	"github.com/andreyst/tracker-messenger-bridge/storage"
+	"github.com/andreyst/tracker-messenger-bridge/templates"
+	"github.com/andreyst/tracker-messenger-bridge/tracker"
+)
+
+// statusTemplates - templates of announced issue status changes by action
+var statusTemplates = map[string]string{
+	tracker.ActionClosed:     templates.IssueClosed,
+	tracker.ActionReopened:   templates.IssueReopened,
+	tracker.ActionLabeled:    templates.IssueLabeled,
+	tracker.ActionUnlabeled:  templates.IssueUnlabeled,
+	tracker.ActionAssigned:   templates.IssueAssigned,
+	tracker.ActionUnassigned: templates.IssueUnassigned,
+}
+
+// issueDigestActions - issue actions collected for digests, with digest actions they are listed under
+var issueDigestActions = map[string]string{
+	tracker.ActionOpened:   storage.DigestActionOpened,
+	tracker.ActionClosed:   storage.DigestActionClosed,
+	tracker.ActionReopened: storage.DigestActionReopened,
+}
+
+// IssueEventHandler - announces new issues and their status changes
+// Status changes are posted as replies to the original issue message,
+// edits of title or description update the original message
+type IssueEventHandler struct{}
+
+// Handle - handle event
+func (IssueEventHandler) Handle(ctx context.Context, b *bot.Bot, env bot.Envelope) (bool, error) {
+	issue, ok := env.Payload.(tracker.IssueEvent)
+	if !ok {
+		return false, nil
+	}
+
+	log.Printf("Issue %s: %s#%d\n", issue.Action, issue.Repo.FullName, issue.Issue.Number)
+
+	linked := bot.Issue{
+		Owner:       issue.Repo.Owner,
+		Repo:        issue.Repo.Name,
+		Number:      issue.Issue.Number,
+		URL:         issue.Issue.URL,
+		Author:      issue.Issue.Author.Login,
+		AuthorURL:   issue.Issue.Author.URL,
+		Title:       issue.Issue.Title,
+		Description: issue.Issue.Body,
+	}
+
+	labels := issue.Issue.Labels
+	chats := b.Router.Targets(issue.Repo.FullName, routing.EventIssues, labels)
+
+	if digestAction, ok := issueDigestActions[issue.Action]; ok {
+		err := b.CollectForDigest(routing.EventIssues, labels, storage.DigestEvent{
+			Key:    fmt.Sprintf("issue:%d:%s:%d", issue.Issue.ID, issue.Action, issue.Issue.UpdatedAt.Unix()),
+			Repo:   issue.Repo.FullName,
+			Number: issue.Issue.Number,
+			Title:  issue.Issue.Title,
+			URL:    issue.Issue.URL,
+			Action: digestAction,
+		})
+		if err != nil {
+			return true, err
+		}
+	}
+
+	if issue.Action == tracker.ActionOpened {
+		threads := openIssueTopics(ctx, b, chats, linked)
+		msg := issueMessage(issue, templates.IssueOpened)
+		err := sendToChats(ctx, b, deliveryKey(env, msg), chats, msg, threads, func(chatID int64, messageID int64) error {
+			return b.LinkIssue(chatID, messageID, linked)
+		})
+
+		return true, err
+	}
+
+	if issue.Action == tracker.ActionEdited {
+		return true, editIssueMessages(ctx, b, issue, linked)
+	}
+
+	key, ok := statusTemplates[issue.Action]
+	switch {
+	case !ok:
+		// Other actions are not announced
+		return true, nil
+	case strings.HasSuffix(issue.Action, "labeled") && issue.Label == "",
+		strings.HasSuffix(issue.Action, "assigned") && issue.Assignee.Login == "":
+		// Nothing to announce
+		return true, nil
+	}
+
+	threads, err := issueThreads(b, linked.Owner, linked.Repo, linked.Number)
+	if err != nil {
+		return true, err
+	}
+
+	if issue.Action == tracker.ActionReopened {
+		// Topic has to be open before status is posted to it
+		err = b.SetIssueTopicsClosed(ctx, linked.Owner, linked.Repo, linked.Number, false)
+		if err != nil {
+			return true, err
+		}
+	}
+
+	// Status messages are linked to the issue too, so that replies to them are posted as comments
+	msg := issueMessage(issue, key)
+	err = sendToChats(ctx, b, deliveryKey(env, msg), chats, msg, threads, func(chatID int64, messageID int64) error {
+		return b.LinkIssueStatus(chatID, messageID, linked)
+	})
+
+	if issue.Action == tracker.ActionClosed {
+		closeIssueTopics(ctx, b, linked)
+	}
+
+	return true, err
+}
+
+// issueMessage - message about issue rendered with template
+func issueMessage(issue tracker.IssueEvent, template string) message {
+	data := templates.Data{
+		Repo:        issue.Repo.FullName,
+		Number:      issue.Issue.Number,
+		Title:       issue.Issue.Title,
+		URL:         issue.Issue.URL,
+		Author:      issue.Issue.Author.Login,
+		AuthorURL:   issue.Issue.Author.URL,
+		Sender:      issue.Sender.Login,
+		SenderURL:   issue.Sender.URL,
+		Body:        issue.Issue.Body,
+		Labels:      issue.Issue.Labels,
+		Label:       issue.Label,
+		Assignee:    issue.Assignee.Login,
+		AssigneeURL: issue.Assignee.URL,
+	}
+
+	return message{template: template, data: data, moreURL: issue.Issue.URL}
+}
+
+// editIssueMessages - updates original issue messages after title or description change
+func editIssueMessages(ctx context.Context, b *bot.Bot, issue tracker.IssueEvent, linked bot.Issue) error {
+	messages, err := b.IssueMessages(linked.Owner, linked.Repo, linked.Number)
+	if err != nil {
+		return fmt.Errorf("unable to look up issue messages: %w", err)
+	}
+
+	return editMessages(ctx, b, messages, issueMessage(issue, templates.IssueOpened), func(chatID int64, messageID int64) error {
+		return b.LinkIssue(chatID, messageID, linked)
+	})
+}
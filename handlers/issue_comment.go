@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/andreyst/tracker-messenger-bridge/bot"
+	"github.com/andreyst/tracker-messenger-bridge/routing"
+	"github.com/andreyst/tracker-messenger-bridge/storage"
+	"github.com/andreyst/tracker-messenger-bridge/templates"
+	"github.com/andreyst/tracker-messenger-bridge/tracker"
+)
+
+// IssueCommentEventHandler - relays new comments, edits and deletions of relayed comments
+type IssueCommentEventHandler struct{}
+
+// Handle - handle event
+func (IssueCommentEventHandler) Handle(ctx context.Context, b *bot.Bot, env bot.Envelope) (bool, error) {
+	comment, ok := env.Payload.(tracker.CommentEvent)
+	if !ok {
+		return false, nil
+	}
+
+	log.Printf("Comment %s: %s#%d\n", comment.Action, comment.Repo.FullName, comment.Issue.Number)
+
+	ok, err := b.IsOwnComment(comment.Comment.ID)
+	if err != nil {
+		return true, fmt.Errorf("unable to look up comment link: %w", err)
+	}
+	if ok {
+		if comment.Action == tracker.ActionDeleted {
+			// Messenger reply stays, but it is not linked to anything anymore
+			return true, b.UnlinkComment(comment.Comment.ID)
+		}
+		// Own comment, skipping
+		return true, nil
+	}
+
+	linked := bot.Comment{
+		ID:          comment.Comment.ID,
+		URL:         comment.Comment.URL,
+		IssueOwner:  comment.Repo.Owner,
+		IssueRepo:   comment.Repo.Name,
+		IssueNumber: comment.Issue.Number,
+		IssueURL:    comment.Issue.URL,
+		Author:      comment.Comment.Author.Login,
+		AuthorURL:   comment.Comment.Author.URL,
+		Body:        comment.Comment.Body,
+	}
+
+	switch comment.Action {
+	case tracker.ActionCreated:
+		labels := comment.Issue.Labels
+
+		err = b.CollectForDigest(routing.EventIssueComment, labels, storage.DigestEvent{
+			Key:         fmt.Sprintf("comment:%d", comment.Comment.ID),
+			Repo:        comment.Repo.FullName,
+			Number:      comment.Issue.Number,
+			PullRequest: comment.Issue.PullRequest,
+			Title:       comment.Issue.Title,
+			URL:         comment.Issue.URL,
+			Action:      storage.DigestActionCommented,
+		})
+		if err != nil {
+			return true, err
+		}
+
+		// Comments are posted as replies to the original issue message, in its topic if there is one
+		threads, err := issueThreads(b, linked.IssueOwner, linked.IssueRepo, linked.IssueNumber)
+		if err != nil {
+			return true, err
+		}
+
+		chats := b.Router.Targets(comment.Repo.FullName, routing.EventIssueComment, labels)
+		msg := commentMessage(comment, templates.IssueCommentCreated)
+		err = sendToChats(ctx, b, deliveryKey(env, msg), chats, msg, threads, func(chatID int64, messageID int64) error {
+			return b.LinkComment(chatID, messageID, linked)
+		})
+
+		return true, err
+	case tracker.ActionEdited:
+		messages, err := b.CommentMessages(comment.Comment.ID)
+		if err != nil {
+			return true, fmt.Errorf("unable to look up comment messages: %w", err)
+		}
+
+		return true, editMessages(ctx, b, messages, commentMessage(comment, templates.IssueCommentCreated), func(chatID int64, messageID int64) error {
+			return b.LinkComment(chatID, messageID, linked)
+		})
+	case tracker.ActionDeleted:
+		messages, err := b.CommentMessages(comment.Comment.ID)
+		if err != nil {
+			return true, fmt.Errorf("unable to look up comment messages: %w", err)
+		}
+
+		err = editMessages(ctx, b, messages, commentMessage(comment, templates.IssueCommentDeleted), nil)
+		if err != nil {
+			return true, err
+		}
+
+		// Replies to deleted comment have nothing to quote
+		return true, b.UnlinkComment(comment.Comment.ID)
+	}
+
+	return true, nil
+}
+
+// commentMessage - message about issue comment rendered with template
+func commentMessage(comment tracker.CommentEvent, template string) message {
+	data := templates.Data{
+		Repo:       comment.Repo.FullName,
+		Number:     comment.Issue.Number,
+		Title:      comment.Issue.Title,
+		URL:        comment.Issue.URL,
+		Author:     comment.Comment.Author.Login,
+		AuthorURL:  comment.Comment.Author.URL,
+		Sender:     comment.Sender.Login,
+		SenderURL:  comment.Sender.URL,
+		Body:       comment.Comment.Body,
+		CommentURL: comment.Comment.URL,
+		Labels:     comment.Issue.Labels,
+	}
+
+	return message{template: template, data: data, moreURL: comment.Comment.URL}
+}
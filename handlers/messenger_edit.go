@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	bot "github.com/andreyst/tracker-messenger-bridge/bot"
+	"github.com/andreyst/tracker-messenger-bridge/messenger"
+	"github.com/andreyst/tracker-messenger-bridge/permissions"
+	"github.com/andreyst/tracker-messenger-bridge/tracker"
+)
+
+// MessengerEditEventHandler - updates tracker comments after messenger replies they were created from are edited
+type MessengerEditEventHandler struct{}
+
+// Handle - handles update
+func (MessengerEditEventHandler) Handle(ctx context.Context, b *bot.Bot, env bot.Envelope) (bool, error) {
+	update, ok := env.Payload.(messenger.Update)
+	if !ok {
+		return false, nil
+	}
+
+	message := update.EditedMessage
+	if message == nil || message.From == nil {
+		return false, nil
+	}
+
+	chatID := message.ChatID
+	obj, err := b.LinkedObject(chatID, message.ID)
+	if err != nil {
+		return false, fmt.Errorf("unable to look up message link: %w", err)
+	}
+	reply, ok := obj.(bot.Reply)
+	if !ok {
+		// Only replies bridged to tracker are synchronized
+		return false, nil
+	}
+
+	allowed, _, err := b.Authorize(env, message.From, chatID, reply.IssueOwner+"/"+reply.IssueRepo, "edit reply", permissions.Staff)
+	if err != nil {
+		return false, err
+	}
+	if !allowed {
+		// Refusal was already explained when reply was posted
+		return true, nil
+	}
+
+	// Comment can be edited only by account it was posted as, not by one user linked later
+	t, err := b.ReplyTracker(message.From.ID, reply)
+	if err != nil {
+		return false, fmt.Errorf("unable to look up identity: %w", err)
+	}
+	if t == nil {
+		log.Printf("Not editing comment %d, its author %s is not linked anymore\n", reply.CommentID, reply.AuthorLogin)
+		return true, nil
+	}
+
+	text, err := messageMarkdown(ctx, b, message)
+	if err != nil {
+		return true, err
+	}
+	body := reply.Prefix + text
+	if reply.Review {
+		reviews, ok := t.(tracker.ReviewComments)
+		if !ok {
+			return true, fmt.Errorf("review comments not supported by %s", t.Name())
+		}
+		err = reviews.EditReviewComment(ctx, reply.IssueOwner, reply.IssueRepo, reply.CommentID, body)
+	} else {
+		err = t.EditComment(ctx, reply.IssueOwner, reply.IssueRepo, reply.CommentID, body)
+	}
+	if err != nil {
+		return true, fmt.Errorf("unable to edit comment %d: %w", reply.CommentID, err)
+	}
+
+	return true, nil
+}
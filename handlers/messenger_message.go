@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/andreyst/tracker-messenger-bridge/bot"
+	"github.com/andreyst/tracker-messenger-bridge/messenger"
+)
+
+var imageExts = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true}
+
+var labelReplacer = strings.NewReplacer("[", "(", "]", ")")
+
+// errTooLarge - attachment is larger than attachments.max_size
+var errTooLarge = errors.New("file is too large")
+
+// sizeLimitReader - fails with errTooLarge once more than limit bytes are read,
+// so that files of unknown size are not stored partially
+type sizeLimitReader struct {
+	r     io.Reader
+	limit int64
+	read  int64
+}
+
+func newSizeLimitReader(r io.Reader, limit int64) *sizeLimitReader {
+	return &sizeLimitReader{r: io.LimitReader(r, limit+1), limit: limit}
+}
+
+func (l *sizeLimitReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.limit {
+		return n, errTooLarge
+	}
+
+	return n, err
+}
+
+// messageMarkdown - returns Github Markdown of messenger message,
+// attached files are stored in b.Blobs and embedded as images or links.
+// Returns empty string for messages without text and attachments, e.g. polls
+func messageMarkdown(ctx context.Context, b *bot.Bot, message *messenger.Message) (string, error) {
+	var parts []string
+	for _, a := range message.Attachments {
+		embedded, err := embedAttachment(ctx, b, a)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, embedded)
+	}
+	if text := strings.TrimSpace(message.Markdown); text != "" {
+		parts = append(parts, text)
+	}
+
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// embedAttachment - downloads file from messenger, stores it and returns Markdown image or link to it
+func embedAttachment(ctx context.Context, b *bot.Bot, a messenger.Attachment) (string, error) {
+	label := labelReplacer.Replace(a.Label)
+	if b.Blobs == nil {
+		return fmt.Sprintf("*(%s is not bridged)*", label), nil
+	}
+	// Size is not known for some attachments, it is checked while downloading too
+	if a.Size > b.Config.Attachments.MaxSize {
+		return fmt.Sprintf("*(%s is too large to bridge)*", label), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	file, fileName, err := b.Messenger.Download(ctx, a.FileID)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	ext := strings.ToLower(path.Ext(fileName))
+	name := a.Name
+	if path.Ext(name) == "" {
+		name += ext
+	}
+
+	stored, err := b.Blobs.Put(ctx, name, newSizeLimitReader(file, int64(b.Config.Attachments.MaxSize)))
+	if errors.Is(err, errTooLarge) {
+		return fmt.Sprintf("*(%s is too large to bridge)*", label), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to store file %s: %w", a.Label, err)
+	}
+
+	if imageExts[ext] {
+		return fmt.Sprintf("![%s](%s)", label, stored), nil
+	}
+
+	return fmt.Sprintf("[📎 %s](%s)", label, stored), nil
+}
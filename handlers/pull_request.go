@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+
+	"github.com/andreyst/tracker-messenger-bridge/bot"
+	"github.com/andreyst/tracker-messenger-bridge/routing"
+	"github.com/andreyst/tracker-messenger-bridge/storage"
+	"github.com/andreyst/tracker-messenger-bridge/templates"
+	"github.com/andreyst/tracker-messenger-bridge/tracker"
+)
+
+// closingKeywordRe - matches Github and Gitea keywords linking pull request to issues it closes, e.g. "Fixes #12"
+var closingKeywordRe = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+#(\d+)\b`)
+
+// PullRequestEventHandler - announces opened, closed, merged and reopened pull requests
+type PullRequestEventHandler struct{}
+
+// Handle - handle event
+func (PullRequestEventHandler) Handle(ctx context.Context, b *bot.Bot, env bot.Envelope) (bool, error) {
+	pr, ok := env.Payload.(tracker.PullRequestEvent)
+	if !ok {
+		return false, nil
+	}
+
+	log.Printf("Pull request %s: %s#%d\n", pr.Action, pr.Repo.FullName, pr.PullRequest.Number)
+
+	var key, action string
+	switch {
+	case pr.Action == tracker.ActionOpened:
+		key, action = templates.PullRequestOpened, storage.DigestActionOpened
+	case pr.Action == tracker.ActionClosed && pr.Merged:
+		key, action = templates.PullRequestMerged, storage.DigestActionMerged
+	case pr.Action == tracker.ActionClosed:
+		key, action = templates.PullRequestClosed, storage.DigestActionClosed
+	case pr.Action == tracker.ActionReopened:
+		key, action = templates.PullRequestReopened, storage.DigestActionReopened
+	default:
+		// Other actions are not announced
+		return true, nil
+	}
+
+	labels := pr.PullRequest.Labels
+	msg := message{
+		template: key,
+		data: templates.Data{
+			Repo:      pr.Repo.FullName,
+			Number:    pr.PullRequest.Number,
+			Title:     pr.PullRequest.Title,
+			URL:       pr.PullRequest.URL,
+			Labels:    labels,
+			Author:    pr.PullRequest.Author.Login,
+			AuthorURL: pr.PullRequest.Author.URL,
+			Sender:    pr.Sender.Login,
+			SenderURL: pr.Sender.URL,
+			Body:      pr.PullRequest.Body,
+		},
+		moreURL: pr.PullRequest.URL,
+	}
+
+	// Replies to pull request messages are posted to pull request conversation
+	linked := pullRequestLink(pr.Repo, pr.PullRequest)
+
+	err := b.CollectForDigest(routing.EventPullRequest, labels, storage.DigestEvent{
+		Key:         fmt.Sprintf("pull_request:%d:%s:%d", pr.PullRequest.ID, action, pr.PullRequest.UpdatedAt.Unix()),
+		Repo:        pr.Repo.FullName,
+		Number:      pr.PullRequest.Number,
+		PullRequest: true,
+		Title:       pr.PullRequest.Title,
+		URL:         pr.PullRequest.URL,
+		Action:      action,
+	})
+	if err != nil {
+		return true, err
+	}
+
+	chats := b.Router.Targets(pr.Repo.FullName, routing.EventPullRequest, labels)
+	if pr.Action == tracker.ActionOpened {
+		threads := openIssueTopics(ctx, b, chats, linked)
+		err := sendToChats(ctx, b, deliveryKey(env, msg), chats, msg, threads, func(chatID int64, messageID int64) error {
+			return b.LinkIssue(chatID, messageID, linked)
+		})
+		announceInClosedIssues(ctx, b, env, pr, chats, msg)
+
+		return true, err
+	}
+
+	// Status changes are posted as replies to the original pull request message
+	threads, err := issueThreads(b, linked.Owner, linked.Repo, linked.Number)
+	if err != nil {
+		return true, err
+	}
+
+	if pr.Action == tracker.ActionReopened {
+		// Topic has to be open before status is posted to it
+		err = b.SetIssueTopicsClosed(ctx, linked.Owner, linked.Repo, linked.Number, false)
+		if err != nil {
+			return true, err
+		}
+	}
+
+	err = sendToChats(ctx, b, deliveryKey(env, msg), chats, msg, threads, func(chatID int64, messageID int64) error {
+		return b.LinkIssueStatus(chatID, messageID, linked)
+	})
+
+	if pr.Action == tracker.ActionClosed {
+		closeIssueTopics(ctx, b, linked)
+	}
+
+	return true, err
+}
+
+// pullRequestLink - pull request messages are linked to, replies to them are posted to pull request conversation
+func pullRequestLink(repo tracker.Repo, pr tracker.Issue) bot.Issue {
+	return bot.Issue{
+		Owner:       repo.Owner,
+		Repo:        repo.Name,
+		Number:      pr.Number,
+		URL:         pr.URL,
+		Author:      pr.Author.Login,
+		AuthorURL:   pr.Author.URL,
+		Title:       pr.Title,
+		Description: pr.Body,
+	}
+}
+
+// announceInClosedIssues - posts link to new pull request to threads of issues it closes in chats pull request is routed to,
+// errors are not returned, so that pull request is not posted again
+func announceInClosedIssues(ctx context.Context, b *bot.Bot, env bot.Envelope, pr tracker.PullRequestEvent, chats []int64, msg message) {
+	msg.template = templates.PullRequestLinked
+
+	seen := make(map[int64]bool)
+	for _, match := range closingKeywordRe.FindAllStringSubmatch(pr.PullRequest.Title+"\n"+pr.PullRequest.Body, -1) {
+		number, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || seen[number] || number == pr.PullRequest.Number {
+			continue
+		}
+		seen[number] = true
+
+		threads, err := issueThreads(b, pr.Repo.Owner, pr.Repo.Name, number)
+		if err != nil {
+			fmt.Printf("Error looking up threads of issue %d: %v\n", number, err)
+			continue
+		}
+
+		var issueChats []int64
+		for _, chatID := range chats {
+			if _, ok := threads[chatID]; ok {
+				issueChats = append(issueChats, chatID)
+			}
+		}
+
+		err = sendToChats(ctx, b, deliveryKey(env, msg, number), issueChats, msg, threads, nil)
+		if err != nil {
+			fmt.Printf("Error announcing pull request in issue %d: %v\n", number, err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/andreyst/tracker-messenger-bridge/bot"
+	"github.com/andreyst/tracker-messenger-bridge/routing"
+	"github.com/andreyst/tracker-messenger-bridge/storage"
+	"github.com/andreyst/tracker-messenger-bridge/templates"
+	"github.com/andreyst/tracker-messenger-bridge/tracker"
+)
+
+var reviewStates = map[string]string{
+	tracker.ReviewApproved:         "approved",
+	tracker.ReviewChangesRequested: "requested changes",
+	tracker.ReviewCommented:        "commented",
+}
+
+// PullRequestReviewEventHandler - announces submitted pull request reviews
+type PullRequestReviewEventHandler struct{}
+
+// Handle - handle event
+func (PullRequestReviewEventHandler) Handle(ctx context.Context, b *bot.Bot, env bot.Envelope) (bool, error) {
+	review, ok := env.Payload.(tracker.ReviewEvent)
+	if !ok {
+		return false, nil
+	}
+
+	log.Printf("Pull request review %s: %s#%d\n", review.Action, review.Repo.FullName, review.PullRequest.Number)
+
+	if review.Action != tracker.ActionSubmitted {
+		return true, nil
+	}
+
+	state, ok := reviewStates[review.Review.State]
+	if !ok {
+		state = review.Review.State
+	}
+
+	msg := message{
+		template: templates.PullRequestReviewSubmitted,
+		data: templates.Data{
+			Repo:       review.Repo.FullName,
+			Number:     review.PullRequest.Number,
+			Title:      review.PullRequest.Title,
+			URL:        review.PullRequest.URL,
+			Author:     review.Review.Author.Login,
+			AuthorURL:  review.Review.Author.URL,
+			Sender:     review.Sender.Login,
+			SenderURL:  review.Sender.URL,
+			Body:       review.Review.Body,
+			CommentURL: review.Review.URL,
+			State:      state,
+		},
+		moreURL: review.Review.URL,
+	}
+
+	// Reviews are posted as replies to the original pull request message,
+	// replies to review messages are posted to pull request conversation
+	linked := pullRequestLink(review.Repo, review.PullRequest)
+
+	// Reviews without ID are told apart by pull request update time
+	err := b.CollectForDigest(routing.EventPullRequestReview, nil, storage.DigestEvent{
+		Key:         fmt.Sprintf("review:%d:%d:%d", review.PullRequest.ID, review.Review.ID, review.PullRequest.UpdatedAt.Unix()),
+		Repo:        review.Repo.FullName,
+		Number:      review.PullRequest.Number,
+		PullRequest: true,
+		Title:       review.PullRequest.Title,
+		URL:         review.PullRequest.URL,
+		Action:      storage.DigestActionReviewed,
+	})
+	if err != nil {
+		return true, err
+	}
+
+	threads, err := issueThreads(b, linked.Owner, linked.Repo, linked.Number)
+	if err != nil {
+		return true, err
+	}
+
+	chats := b.Router.Targets(review.Repo.FullName, routing.EventPullRequestReview, nil)
+	err = sendToChats(ctx, b, deliveryKey(env, msg), chats, msg, threads, func(chatID int64, messageID int64) error {
+		return b.LinkIssueStatus(chatID, messageID, linked)
+	})
+
+	return true, err
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/andreyst/tracker-messenger-bridge/bot"
+	"github.com/andreyst/tracker-messenger-bridge/routing"
+	"github.com/andreyst/tracker-messenger-bridge/storage"
+	"github.com/andreyst/tracker-messenger-bridge/templates"
+	"github.com/andreyst/tracker-messenger-bridge/tracker"
+)
+
+// PullRequestReviewCommentEventHandler - relays comments on pull request diff lines, their edits and deletions
+type PullRequestReviewCommentEventHandler struct{}
+
+// Handle - handle event
+func (PullRequestReviewCommentEventHandler) Handle(ctx context.Context, b *bot.Bot, env bot.Envelope) (bool, error) {
+	comment, ok := env.Payload.(tracker.ReviewCommentEvent)
+	if !ok {
+		return false, nil
+	}
+
+	log.Printf("Review comment %s: %s#%d\n", comment.Action, comment.Repo.FullName, comment.PullRequest.Number)
+
+	ok, err := b.IsOwnReviewComment(comment.Comment.ID)
+	if err != nil {
+		return true, fmt.Errorf("unable to look up comment link: %w", err)
+	}
+	if ok {
+		if comment.Action == tracker.ActionDeleted {
+			// Messenger reply stays, but it is not linked to anything anymore
+			return true, b.UnlinkReviewComment(comment.Comment.ID)
+		}
+		// Own comment, skipping
+		return true, nil
+	}
+
+	linked := bot.ReviewComment{
+		ID:         comment.Comment.ID,
+		URL:        comment.Comment.URL,
+		Owner:      comment.Repo.Owner,
+		Repo:       comment.Repo.Name,
+		PullNumber: comment.PullRequest.Number,
+		PullURL:    comment.PullRequest.URL,
+		Author:     comment.Comment.Author.Login,
+		AuthorURL:  comment.Comment.Author.URL,
+		Body:       comment.Comment.Body,
+	}
+
+	switch comment.Action {
+	case tracker.ActionCreated:
+		err = b.CollectForDigest(routing.EventPullRequestReviewComment, nil, storage.DigestEvent{
+			Key:         fmt.Sprintf("review_comment:%d", comment.Comment.ID),
+			Repo:        comment.Repo.FullName,
+			Number:      comment.PullRequest.Number,
+			PullRequest: true,
+			Title:       comment.PullRequest.Title,
+			URL:         comment.PullRequest.URL,
+			Action:      storage.DigestActionCommented,
+		})
+		if err != nil {
+			return true, err
+		}
+
+		// Comments are posted as replies to the original pull request message, in its topic if there is one
+		threads, err := issueThreads(b, linked.Owner, linked.Repo, linked.PullNumber)
+		if err != nil {
+			return true, err
+		}
+
+		chats := b.Router.Targets(comment.Repo.FullName, routing.EventPullRequestReviewComment, nil)
+		msg := reviewCommentMessage(comment, templates.PullRequestReviewCommentCreated)
+		err = sendToChats(ctx, b, deliveryKey(env, msg), chats, msg, threads, func(chatID int64, messageID int64) error {
+			return b.LinkReviewComment(chatID, messageID, linked)
+		})
+
+		return true, err
+	case tracker.ActionEdited:
+		messages, err := b.ReviewCommentMessages(comment.Comment.ID)
+		if err != nil {
+			return true, fmt.Errorf("unable to look up comment messages: %w", err)
+		}
+
+		return true, editMessages(ctx, b, messages, reviewCommentMessage(comment, templates.PullRequestReviewCommentCreated), func(chatID int64, messageID int64) error {
+			return b.LinkReviewComment(chatID, messageID, linked)
+		})
+	case tracker.ActionDeleted:
+		messages, err := b.ReviewCommentMessages(comment.Comment.ID)
+		if err != nil {
+			return true, fmt.Errorf("unable to look up comment messages: %w", err)
+		}
+
+		err = editMessages(ctx, b, messages, reviewCommentMessage(comment, templates.PullRequestReviewCommentDeleted), nil)
+		if err != nil {
+			return true, err
+		}
+
+		// Replies to deleted comment would fail to thread
+		return true, b.UnlinkReviewComment(comment.Comment.ID)
+	}
+
+	return true, nil
+}
+
+// reviewCommentMessage - message about review comment rendered with template
+func reviewCommentMessage(comment tracker.ReviewCommentEvent, template string) message {
+	data := templates.Data{
+		Repo:       comment.Repo.FullName,
+		Number:     comment.PullRequest.Number,
+		Title:      comment.PullRequest.Title,
+		URL:        comment.PullRequest.URL,
+		Author:     comment.Comment.Author.Login,
+		AuthorURL:  comment.Comment.Author.URL,
+		Sender:     comment.Sender.Login,
+		SenderURL:  comment.Sender.URL,
+		Body:       comment.Comment.Body,
+		CommentURL: comment.Comment.URL,
+		Path:       comment.Comment.Path,
+	}
+
+	return message{template: template, data: data, moreURL: comment.Comment.URL}
+}
@@ -3,44 +3,54 @@ package handlers
 import (
 	"context"
 	"fmt"
-	"log"
 	"regexp"
 
 	bot "github.com/andreyst/tracker-messenger-bridge/bot"
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
-	"github.com/google/go-github/github"
+	"github.com/andreyst/tracker-messenger-bridge/messenger"
+	"github.com/andreyst/tracker-messenger-bridge/permissions"
+	"github.com/andreyst/tracker-messenger-bridge/tracker"
 )
 
-// ReplyToCommentEventHandler - explains channel bumping policy
+// ReplyToCommentEventHandler - posts messenger replies to bridged issues, comments and review comments
+// as tracker comments, on behalf of linked identity if sender has one
 type ReplyToCommentEventHandler struct{}
 
 // Handle - handles update
-func (ReplyToCommentEventHandler) Handle(b *bot.Bot, event interface{}) bool {
-	update, ok := event.(tgbotapi.Update)
+func (ReplyToCommentEventHandler) Handle(ctx context.Context, b *bot.Bot, env bot.Envelope) (bool, error) {
+	update, ok := env.Payload.(messenger.Update)
 	if !ok {
-		return false
+		return false, nil
 	}
 
 	if update.Message == nil {
-		return false
+		return false, nil
 	}
 
-	if update.Message.ReplyToMessage == nil {
-		return false
+	if update.Message.ReplyTo == nil || update.Message.From == nil {
+		return false, nil
 	}
 
-	_, isOwn := b.MessagesMap[int64(update.Message.MessageID)]
-	source, isOwnReply := b.MessagesMap[int64(update.Message.ReplyToMessage.MessageID)]
-	fmt.Printf("isOwn: %t, isOwnReply: %t\n", isOwn, isOwnReply)
+	chatID := update.Message.ChatID
+	own, err := b.LinkedObject(chatID, update.Message.ID)
+	if err != nil {
+		return false, fmt.Errorf("unable to look up message link: %w", err)
+	}
+	source, err := b.LinkedObject(chatID, update.Message.ReplyTo.ID)
+	if err != nil {
+		return false, fmt.Errorf("unable to look up message link: %w", err)
+	}
+	isOwn := own != nil
+	isOwnReply := source != nil
 	if isOwn || !isOwnReply {
 		// Own comment or not a reply to own comment, skipping
-		return false
+		return false, nil
 	}
 
 	var issueOwner string
 	var issueRepo string
-	var issueNumber int
-	var commentBody string
+	var issueNumber int64
+	var quote string
+	var reviewCommentID int64
 
 	switch source.(type) {
 	case bot.Issue:
@@ -48,37 +58,103 @@ func (ReplyToCommentEventHandler) Handle(b *bot.Bot, event interface{}) bool {
 
 		issueOwner = issue.Owner
 		issueRepo = issue.Repo
-		issueNumber = int(issue.Number)
-
-		commentBody = fmt.Sprintf("%s@ replies:\n%s",
-			update.Message.From.UserName,
-			update.Message.Text,
-		)
+		issueNumber = issue.Number
 	case bot.Comment:
 		comment := source.(bot.Comment)
 
 		issueOwner = comment.IssueOwner
 		issueRepo = comment.IssueRepo
-		issueNumber = int(comment.IssueNumber)
+		issueNumber = comment.IssueNumber
 
 		re := regexp.MustCompile(`(?m)^(.*)$`)
 		sub := `> $1`
-		commentBody = fmt.Sprintf("%s\n\n%s@ replies:\n%s",
-			re.ReplaceAllString(comment.Body, sub),
-			update.Message.From.UserName,
-			update.Message.Text,
-		)
+		quote = re.ReplaceAllString(comment.Body, sub)
+	case bot.ReviewComment:
+		comment := source.(bot.ReviewComment)
+
+		issueOwner = comment.Owner
+		issueRepo = comment.Repo
+		issueNumber = comment.PullNumber
+		// Review comment replies are threaded on the diff line, no need to quote
+		reviewCommentID = comment.ID
+	default:
+		// Replies to bridged replies are not linked to anything to quote
+		return false, nil
+	}
+
+	allowed, refusal, err := b.Authorize(env, update.Message.From, chatID, issueOwner+"/"+issueRepo, "reply", permissions.Staff)
+	if err != nil {
+		return false, err
+	}
+	if !allowed {
+		_, err = b.Messenger.Send(ctx, messenger.Outgoing{
+			ChatID:  chatID,
+			Text:    refusal,
+			ReplyTo: update.Message.ID,
+		})
+		if err != nil {
+			fmt.Printf("Error sending refusal: %v\n", err)
+		}
+
+		return true, nil
 	}
 
-	comment := &github.IssueComment{
-		Body: &commentBody,
+	t, identity, err := b.TrackerFor(update.Message.From.ID)
+	if err != nil {
+		return false, fmt.Errorf("unable to look up identity: %w", err)
+	}
+
+	text, err := messageMarkdown(ctx, b, update.Message)
+	if err != nil {
+		return true, err
+	}
+	if text == "" {
+		// Nothing to post, e.g. poll or location
+		return true, nil
+	}
+
+	// Replies of linked users are posted on their behalf and need no attribution
+	var prefix, authorLogin string
+	if identity == nil {
+		prefix = fmt.Sprintf("%s@ replies:\n", update.Message.From.UserName)
+	} else {
+		authorLogin = identity.GithubLogin
+	}
+	if quote != "" {
+		prefix = fmt.Sprintf("%s\n\n%s", quote, prefix)
+	}
+	commentBody := prefix + text
+
+	var commentID int64
+	if reviewCommentID != 0 {
+		// Review comments are bridged from Github only, which supports replying to them
+		reviews, ok := t.(tracker.ReviewComments)
+		if !ok {
+			return true, fmt.Errorf("tracker %s does not support review comments", t.Name())
+		}
+		commentID, err = reviews.ReplyToReviewComment(ctx, issueOwner, issueRepo, issueNumber, reviewCommentID, commentBody)
+		if err != nil {
+			return true, fmt.Errorf("unable to post reply to review comment: %w", err)
+		}
+	} else {
+		commentID, err = t.CreateComment(ctx, issueOwner, issueRepo, issueNumber, commentBody)
+		if err != nil {
+			return true, fmt.Errorf("unable to post comment to issue: %w", err)
+		}
 	}
 
-	c, _, err := b.GithubClient.Issues.CreateComment(context.Background(), issueOwner, issueRepo, issueNumber, comment)
+	err = b.LinkReply(chatID, update.Message.ID, bot.Reply{
+		CommentID:   commentID,
+		IssueOwner:  issueOwner,
+		IssueRepo:   issueRepo,
+		IssueNumber: issueNumber,
+		Review:      reviewCommentID != 0,
+		Prefix:      prefix,
+		AuthorLogin: authorLogin,
+	})
 	if err != nil {
-		log.Fatalf("Error posting comment to issue: %v\n", err)
+		fmt.Printf("Error saving reply message link: %v\n", err)
 	}
-	b.CommentsMap[*c.ID] = int64(update.Message.MessageID)
 
-	return true
+	return true, nil
 }
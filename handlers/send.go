@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/andreyst/tracker-messenger-bridge/bot"
+	"github.com/andreyst/tracker-messenger-bridge/messenger"
+	"github.com/andreyst/tracker-messenger-bridge/templates"
+)
+
+// message - messenger message rendered from template configured for chat, long body is split into several messages
+type message struct {
+	// Template key, e.g. templates.IssueOpened
+	template string
+	data     templates.Data
+	// Link to full text on tracker, used when body does not fit
+	moreURL string
+}
+
+// parts - renders message for chat into at most maxParts MarkdownV2 texts fitting Telegram limits,
+// which are stricter than limits of other messengers
+func (m message) parts(b *bot.Bot, chatID int64, maxParts int) ([]string, error) {
+	rendered, err := b.Templates.Render(m.template, chatID, m.data)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Markdown.RenderParts(rendered.Header, rendered.Body, rendered.Footer, b.Tracker.DisplayName(), m.moreURL, maxParts), nil
+}
+
+// thread - where message goes in chat, zero values mean top level of chat
+type thread struct {
+	// Message to reply to with the first part
+	replyTo int64
+	// Forum topic to post all parts to
+	topic int64
+}
+
+// deliveryKey - identifies message sent while handling event, so that parts sent before handling
+// was retried are not sent again, empty for events without ID
+func deliveryKey(env bot.Envelope, m message, extra ...interface{}) string {
+	if env.ID == "" {
+		return ""
+	}
+
+	key := fmt.Sprintf("%s/%s/%s", env.Source, env.ID, m.template)
+	for _, e := range extra {
+		key += fmt.Sprintf("/%v", e)
+	}
+
+	return key
+}
+
+// sendToChats - sends message to chats and links every sent part with link.
+// Messages are posted to threads of chats (chat ID -> thread), if there are any, link may be nil.
+// Parts already sent with the same delivery key are skipped, empty key disables that.
+// Sending continues to other chats after errors, first error is returned
+func sendToChats(ctx context.Context, b *bot.Bot, delivery string, chats []int64, m message, threads map[int64]thread, link func(chatID int64, messageID int64) error) error {
+	var firstErr error
+	for _, chatID := range chats {
+		parts, err := m.parts(b, chatID, b.Config.Telegram.MaxMessageParts)
+		if err != nil {
+			fmt.Printf("Error rendering message: %v\n", err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("unable to render message for chat %d: %w", chatID, err)
+			}
+			continue
+		}
+
+		sent := make(map[int]int64)
+		if delivery != "" {
+			sent, err = b.SentParts(delivery, chatID)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("unable to look up parts sent to chat %d: %w", chatID, err)
+				}
+				continue
+			}
+		}
+
+		for i, part := range parts {
+			if _, ok := sent[i]; ok {
+				// Sent before handling was retried, linked then too
+				continue
+			}
+
+			msg := messenger.Outgoing{
+				ChatID: chatID,
+				Text:   part,
+				Format: messenger.FormatMarkdown,
+				Thread: threads[chatID].topic,
+			}
+			if i == 0 {
+				msg.ReplyTo = threads[chatID].replyTo
+			}
+			messageID, err := b.Messenger.Send(ctx, msg)
+			if err != nil {
+				fmt.Printf("Error sending to %s: %v\n", b.Messenger.Name(), err)
+				if firstErr == nil {
+					firstErr = fmt.Errorf("unable to send part %d to chat %d: %w", i+1, chatID, err)
+				}
+				// Remaining parts would make no sense without this one
+				break
+			}
+			if delivery != "" {
+				err = b.SaveSentPart(delivery, chatID, i, messageID)
+				if err != nil {
+					fmt.Printf("Error saving sent part: %v\n", err)
+				}
+			}
+
+			if link == nil {
+				continue
+			}
+			// Every part is linked, so that replies to any of them are bridged
+			err = link(chatID, messageID)
+			if err != nil {
+				fmt.Printf("Error saving message link: %v\n", err)
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// editMessages - replaces text of bot messages with message and relinks every edited message with link,
+// link may be nil. Message is split into as many parts as there are messages in chat,
+// spare messages are emptied. Editing continues to other messages after errors, first error is returned
+func editMessages(ctx context.Context, b *bot.Bot, messages []bot.LinkedMessage, m message, link func(chatID int64, messageID int64) error) error {
+	var chats []int64
+	chatMessages := make(map[int64][]bot.LinkedMessage)
+	for _, message := range messages {
+		if _, ok := chatMessages[message.ChatID]; !ok {
+			chats = append(chats, message.ChatID)
+		}
+		chatMessages[message.ChatID] = append(chatMessages[message.ChatID], message)
+	}
+
+	var firstErr error
+	for _, chatID := range chats {
+		parts, err := m.parts(b, chatID, len(chatMessages[chatID]))
+		if err != nil {
+			fmt.Printf("Error rendering message: %v\n", err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("unable to render message for chat %d: %w", chatID, err)
+			}
+			continue
+		}
+
+		for i, message := range chatMessages[chatID] {
+			text := "…"
+			if i < len(parts) {
+				text = parts[i]
+			}
+
+			err := b.Messenger.Edit(ctx, message.MessageID, messenger.Outgoing{
+				ChatID: message.ChatID,
+				Text:   text,
+				Format: messenger.FormatMarkdown,
+			})
+			if err != nil {
+				fmt.Printf("Error editing %s message: %v\n", b.Messenger.Name(), err)
+				if firstErr == nil {
+					firstErr = fmt.Errorf("unable to edit message %d in chat %d: %w", message.MessageID, message.ChatID, err)
+				}
+				continue
+			}
+
+			if link == nil {
+				continue
+			}
+			err = link(message.ChatID, message.MessageID)
+			if err != nil {
+				fmt.Printf("Error saving message link: %v\n", err)
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// issueThreads - finds the original issue message to reply to and forum topic of issue in every chat
+func issueThreads(b *bot.Bot, owner string, repo string, number int64) (map[int64]thread, error) {
+	messages, err := b.IssueMessages(owner, repo, number)
+	if err != nil {
+		return nil, fmt.Errorf("unable to look up issue messages: %w", err)
+	}
+	topics, err := b.IssueTopics(owner, repo, number)
+	if err != nil {
+		return nil, fmt.Errorf("unable to look up issue topics: %w", err)
+	}
+
+	threads := make(map[int64]thread)
+	for _, message := range messages {
+		if _, ok := threads[message.ChatID]; !ok {
+			threads[message.ChatID] = thread{replyTo: message.MessageID, topic: topics[message.ChatID]}
+		}
+	}
+	for chatID, topic := range topics {
+		if _, ok := threads[chatID]; !ok {
+			threads[chatID] = thread{topic: topic}
+		}
+	}
+
+	return threads, nil
+}
+
+// openIssueTopics - creates topics for new issue or pull request in chats using topics.
+// Issue is posted to top level of chats where topic could not be created, e.g. when topics are disabled
+func openIssueTopics(ctx context.Context, b *bot.Bot, chats []int64, issue bot.Issue) map[int64]thread {
+	existing, err := b.IssueTopics(issue.Owner, issue.Repo, issue.Number)
+	if err != nil {
+		fmt.Printf("Error looking up issue topics: %v\n", err)
+	}
+
+	threads := make(map[int64]thread)
+	for _, chatID := range chats {
+		if !b.UsesTopics(chatID) {
+			continue
+		}
+		if topic, ok := existing[chatID]; ok {
+			// Topic was created by previous attempt to handle event
+			threads[chatID] = thread{topic: topic}
+			continue
+		}
+
+		topic, err := b.CreateIssueTopic(ctx, chatID, issue.Owner, issue.Repo, issue.Number, issue.Title)
+		if err != nil {
+			fmt.Printf("Error creating issue topic: %v\n", err)
+			continue
+		}
+		threads[chatID] = thread{topic: topic}
+	}
+
+	return threads
+}
+
+// closeIssueTopics - closes topics of closed issue or pull request after status was posted to them,
+// errors are not returned, so that status is not posted again
+func closeIssueTopics(ctx context.Context, b *bot.Bot, issue bot.Issue) {
+	err := b.SetIssueTopicsClosed(ctx, issue.Owner, issue.Repo, issue.Number, true)
+	if err != nil {
+		fmt.Printf("Error closing issue topics: %v\n", err)
+	}
+}
@@ -0,0 +1,118 @@
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	deviceCodeURL  = "https://github.com/login/device/code"
+	accessTokenURL = "https://github.com/login/oauth/access_token"
+	deviceGrant    = "urn:ietf:params:oauth:grant-type:device_code"
+)
+
+// DeviceCode - code user enters at VerificationURI to authorize the bot
+type DeviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// ErrExpired - user did not authorize the bot before device code expired
+var ErrExpired = errors.New("device code expired")
+
+// ErrDenied - user refused to authorize the bot
+var ErrDenied = errors.New("access denied")
+
+// RequestDeviceCode - starts OAuth device flow for Github OAuth app
+func RequestDeviceCode(ctx context.Context, clientID string) (*DeviceCode, error) {
+	code := &DeviceCode{}
+	err := post(ctx, deviceCodeURL, url.Values{
+		"client_id": {clientID},
+		"scope":     {"repo"},
+	}, code)
+	if err != nil {
+		return nil, err
+	}
+	if code.DeviceCode == "" {
+		return nil, errors.New("empty device code in response")
+	}
+
+	return code, nil
+}
+
+// WaitForToken - polls Github until user authorizes the bot, returns access token
+func WaitForToken(ctx context.Context, clientID string, code *DeviceCode) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(code.ExpiresIn)*time.Second)
+	defer cancel()
+
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", ErrExpired
+		case <-time.After(interval):
+		}
+
+		var resp struct {
+			AccessToken string `json:"access_token"`
+			Error       string `json:"error"`
+		}
+		err := post(ctx, accessTokenURL, url.Values{
+			"client_id":   {clientID},
+			"device_code": {code.DeviceCode},
+			"grant_type":  {deviceGrant},
+		}, &resp)
+		if err != nil {
+			return "", err
+		}
+
+		switch resp.Error {
+		case "":
+			return resp.AccessToken, nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		case "expired_token":
+			return "", ErrExpired
+		case "access_denied":
+			return "", ErrDenied
+		default:
+			return "", fmt.Errorf("device flow error: %s", resp.Error)
+		}
+	}
+}
+
+func post(ctx context.Context, endpoint string, params url.Values, result interface{}) error {
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s from %s", resp.Status, endpoint)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
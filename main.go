@@ -1,40 +1,224 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/andreyst/tracker-messenger-bridge/blobs"
 	bot "github.com/andreyst/tracker-messenger-bridge/bot"
+	"github.com/andreyst/tracker-messenger-bridge/commands"
+	"github.com/andreyst/tracker-messenger-bridge/config"
+	"github.com/andreyst/tracker-messenger-bridge/filters"
 	"github.com/andreyst/tracker-messenger-bridge/handlers"
+	"github.com/andreyst/tracker-messenger-bridge/permissions"
+	"github.com/andreyst/tracker-messenger-bridge/pollers"
+	"github.com/andreyst/tracker-messenger-bridge/routing"
+	"github.com/andreyst/tracker-messenger-bridge/storage"
+	"github.com/andreyst/tracker-messenger-bridge/templates"
 	"github.com/andreyst/tracker-messenger-bridge/webhooks"
 	"github.com/joho/godotenv"
 )
 
 // Important:
-// TODO: find staff user by telegram
-// TODO: persist maps
 // TODO: find out about single connection
 
-// TODO: make error handling in hooks/updates more robust
-// TODO: redo env vars to configuration options + env vars
-// TODO: check if env vars exist
-
 func main() {
+	configPath := flag.String("config", os.Getenv("CONFIG_PATH"), "path to YAML config file")
+	listDeadLetters := flag.Bool("dead-letters", false, "list events which failed processing and exit")
+	replayDeadLetter := flag.Int64("replay-dead-letter", 0, "process dead letter with given ID again and exit")
+	auditLog := flag.Int("audit-log", 0, "list given number of latest refused actions of messenger users and exit")
+	flag.Parse()
+
 	err := godotenv.Load()
+	if err != nil && !os.IsNotExist(err) {
+		log.Fatalf("Error loading .env file: %v\n", err)
+	}
+
+	cfg, err := config.Load(*configPath)
 	if err != nil {
-		log.Fatal("Error loading .env file")
+		log.Fatalf("Unable to load config: %v\n", err)
 	}
 
-	bot, err := bot.NewBot()
+	// Listing commands only read storage, so they work without messenger and tracker credentials
+	if *listDeadLetters {
+		printDeadLetters(cfg.DBPath)
+		return
+	}
+	if *auditLog > 0 {
+		printAuditLog(cfg.DBPath, *auditLog)
+		return
+	}
+
+	bot, err := bot.NewBot(cfg)
 	if err != nil {
 		log.Fatalf("Unable to create bot: %v\n", err)
 		os.Exit(1)
 	}
 
-	bot.AddWebhook("/github", webhooks.GithubWebhook{})
+	switch {
+	case cfg.Messenger == config.MessengerSlack:
+		bot.AddWebhook(cfg.Slack.EventsPath, webhooks.SlackWebhook{})
+	case cfg.Telegram.Mode == config.TelegramModePolling:
+		bot.AddPoller(bot.Messenger.Name(), pollers.MessengerPoller{})
+	case cfg.Telegram.Mode == config.TelegramModeWebhook:
+		bot.AddWebhook(cfg.Telegram.WebhookPath, webhooks.TelegramWebhook{})
+	}
+	switch cfg.Tracker {
+	case config.TrackerGitea:
+		bot.AddWebhook(cfg.Gitea.WebhookPath, webhooks.GiteaWebhook{})
+	default:
+		bot.AddWebhook(cfg.Github.WebhookPath, webhooks.GithubWebhook{})
+	}
+	bot.AddPoller(pollers.DigestSource, pollers.DigestPoller{})
+
+	if cfg.Attachments.Backend == config.AttachmentsBackendLocal {
+		store, err := blobs.NewLocalStore(cfg.Attachments.Dir, cfg.Attachments.URL)
+		if err != nil {
+			log.Fatalf("Unable to create attachments store: %v\n", err)
+		}
+		bot.Blobs = store
+		bot.AddHTTPHandler(cfg.Attachments.Path, store)
+	}
 
-	bot.AddEventHandler(handlers.NoBumpingEventHandler{})
+	// Slack handles messages starting with / as its own slash commands, which carry no replied message,
+	// so bot commands never reach Slack Events API and are not registered there
+	if cfg.Messenger != config.MessengerSlack {
+		bot.AddEventHandler(handlers.CommandsEventHandler{
+			Registry: commands.NewRegistry(
+				commands.NoUp,
+				commands.Close,
+				commands.Reopen,
+				commands.Label,
+				commands.Assign,
+				commands.New,
+				commands.Delete,
+				commands.Link,
+				commands.Unlink,
+				commands.WhoAmI,
+			),
+		})
+	}
 	bot.AddEventHandler(handlers.ReplyToCommentEventHandler{})
+	bot.AddEventHandler(handlers.MessengerEditEventHandler{})
+	bot.AddEventHandler(handlers.IssueEventHandler{})
+	bot.AddEventHandler(handlers.IssueCommentEventHandler{})
+	bot.AddEventHandler(handlers.PullRequestEventHandler{})
+	bot.AddEventHandler(handlers.PullRequestReviewEventHandler{})
+	bot.AddEventHandler(handlers.PullRequestReviewCommentEventHandler{})
+	bot.AddEventHandler(handlers.GithubInstallationEventHandler{})
+
+	if *replayDeadLetter != 0 {
+		err = bot.ReplayDeadLetter(context.Background(), *replayDeadLetter)
+		if err != nil {
+			log.Fatalf("Unable to replay dead letter %d: %v\n", *replayDeadLetter, err)
+		}
+		log.Printf("Replayed dead letter %d\n", *replayDeadLetter)
+		return
+	}
+
+	go reloadRoutesOnSignal(bot, *configPath)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go cancelOnSignal(cancel)
+
+	err = bot.Start(ctx)
+	if err != nil {
+		log.Fatalf("Bot stopped: %v\n", err)
+	}
+}
+
+// cancelOnSignal - cancels bot context on SIGINT or SIGTERM
+func cancelOnSignal(cancel context.CancelFunc) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	sig := <-signals
+	log.Printf("Received %v\n", sig)
+	cancel()
 
-	bot.Start()
+	// Second signal terminates without waiting for graceful shutdown
+	signal.Reset(syscall.SIGINT, syscall.SIGTERM)
+}
+
+// printDeadLetters - prints events which failed processing
+func printDeadLetters(dbPath string) {
+	db := storage.NewDB(dbPath)
+	defer db.Close()
+
+	dls, err := storage.ListDeadLetters(db)
+	if err != nil {
+		log.Fatalf("Unable to list dead letters: %v\n", err)
+	}
+
+	for _, dl := range dls {
+		fmt.Printf("%d\t%s\t%s\t%s\tattempts: %d\terror: %s\n", dl.RowID, dl.UpdatedAt, dl.Handler, dl.Kind, dl.Attempts, dl.Error)
+	}
+}
+
+// printAuditLog - prints latest refused actions of messenger users
+func printAuditLog(dbPath string, limit int) {
+	db := storage.NewDB(dbPath)
+	defer db.Close()
+
+	entries, err := storage.ListAuditEntries(db, true, limit)
+	if err != nil {
+		log.Fatalf("Unable to list audit log: %v\n", err)
+	}
+
+	for _, e := range entries {
+		fmt.Printf("%s\tuser: %d @%s\tchat: %d\trepo: %s\taction: %s\trole: %s\trequired: %s\n",
+			e.CreatedAt, e.TelegramUserID, e.TelegramUserName, e.ChatID, e.Repo, e.Action, e.Role, e.RequiredRole)
+	}
+}
+
+// reloadRoutesOnSignal - re-reads routes with default chat, permissions, filter rules and message templates from config on SIGHUP,
+// nothing is applied unless the whole config is valid
+func reloadRoutesOnSignal(b *bot.Bot, configPath string) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	for range signals {
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			log.Printf("Unable to reload config: %v\n", err)
+			continue
+		}
+
+		// Sets are built first, so that live ones are replaced only when all of them are valid
+		router, err := routing.NewRouter(cfg.Routes, cfg.DefaultChatID())
+		if err != nil {
+			log.Printf("Unable to reload config: invalid routes: %v\n", err)
+			continue
+		}
+		policy, err := permissions.NewPolicy(cfg.Permissions)
+		if err != nil {
+			log.Printf("Unable to reload config: invalid permissions: %v\n", err)
+			continue
+		}
+		engine, err := filters.NewEngine(cfg.Filters)
+		if err != nil {
+			log.Printf("Unable to reload config: invalid filter rules: %v\n", err)
+			continue
+		}
+		set, err := templates.NewSet(cfg.Templates, b.Markdown)
+		if err != nil {
+			log.Printf("Unable to reload config: invalid message templates: %v\n", err)
+			continue
+		}
+
+		b.Router.Replace(router)
+		b.Permissions.Replace(policy)
+		b.Filters.Replace(engine)
+		b.Templates.Replace(set)
+
+		log.Printf(
+			"Reloaded %d routes, %d permission grants, %d filter rules and %d template sets\n",
+			len(cfg.Routes), len(cfg.Permissions), len(cfg.Filters), len(cfg.Templates),
+		)
+	}
 }
@@ -0,0 +1,57 @@
+package markdown
+
+import (
+	"strings"
+)
+
+var textReplacer = strings.NewReplacer(
+	"\\", "\\\\",
+	"_", "\\_",
+	"*", "\\*",
+	"[", "\\[",
+	"]", "\\]",
+	"(", "\\(",
+	")", "\\)",
+	"~", "\\~",
+	"`", "\\`",
+	">", "\\>",
+	"#", "\\#",
+	"+", "\\+",
+	"-", "\\-",
+	"=", "\\=",
+	"|", "\\|",
+	"{", "\\{",
+	"}", "\\}",
+	".", "\\.",
+	"!", "\\!",
+)
+
+var codeReplacer = strings.NewReplacer(
+	"\\", "\\\\",
+	"`", "\\`",
+)
+
+var urlReplacer = strings.NewReplacer(
+	"\\", "\\\\",
+	")", "\\)",
+)
+
+// Escape - escapes plain text, e.g. title or login, to be shown literally in MarkdownV2
+func Escape(s string) string {
+	return textReplacer.Replace(s)
+}
+
+// EscapeCode - escapes text inside MarkdownV2 code span or code block
+func EscapeCode(s string) string {
+	return codeReplacer.Replace(s)
+}
+
+// EscapeURL - escapes URL inside MarkdownV2 link
+func EscapeURL(s string) string {
+	return urlReplacer.Replace(s)
+}
+
+// Link - renders MarkdownV2 link with plain text
+func Link(text string, url string) string {
+	return "[" + Escape(text) + "](" + EscapeURL(url) + ")"
+}
@@ -0,0 +1,430 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	htmlCommentRe = regexp.MustCompile(`(?s)<!--.*?-->`)
+	fenceRe       = regexp.MustCompile("^(\\s*)(`{3,}|~{3,})\\s*([^`\\s]*)")
+	headingRe     = regexp.MustCompile(`^#{1,6}(?:\s+(.*?))?(?:\s+#+)?\s*$`)
+	ruleRe        = regexp.MustCompile(`^(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	bulletRe      = regexp.MustCompile(`^[-*+]\s+(.*)$`)
+	orderedRe     = regexp.MustCompile(`^(\d{1,9})[.)]\s+(.*)$`)
+	taskRe        = regexp.MustCompile(`^\[([ xX])\]\s+(.*)$`)
+	tableRuleRe   = regexp.MustCompile(`^\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)+\|?$`)
+	langRe        = regexp.MustCompile(`^[A-Za-z0-9_+#.-]+$`)
+	mentionRe     = regexp.MustCompile(`^@([A-Za-z0-9](?:[A-Za-z0-9-]{0,38}))`)
+	autolinkRe    = regexp.MustCompile(`^<((?:https?://|mailto:)[^\s<>]+)>`)
+	lineBreakRe   = regexp.MustCompile(`(?i)^<br\s*/?>`)
+)
+
+// GithubProfileURL - prefix of Github profile URLs
+const GithubProfileURL = "https://github.com/"
+
+// Renderer - renders Markdown of tracker, which is Github flavored Markdown on all supported trackers
+type Renderer struct {
+	// Prefix of tracker profile URLs @mentions link to, e.g. GithubProfileURL, mentions are not linked when empty
+	ProfileURL string
+}
+
+// Render - converts Github flavored Markdown to Telegram MarkdownV2
+// Code blocks, links, emphasis, lists, task lists, quotes and @mentions are converted to Telegram formatting,
+// everything Telegram has no formatting for is shown as escaped text
+func (r Renderer) Render(src string) string {
+	src = strings.Replace(src, "\r\n", "\n", -1)
+	src = htmlCommentRe.ReplaceAllString(src, "")
+	lines := strings.Split(src, "\n")
+
+	var out []string
+	blank := false
+	for i := 0; i < len(lines); i++ {
+		line := strings.Replace(lines[i], "\t", "    ", -1)
+
+		if m := fenceRe.FindStringSubmatch(line); m != nil {
+			indent, fence, lang := m[1], m[2], m[3]
+			var code []string
+			for i++; i < len(lines); i++ {
+				if isClosingFence(lines[i], fence) {
+					break
+				}
+				code = append(code, strings.TrimPrefix(lines[i], indent))
+			}
+			if !langRe.MatchString(lang) {
+				lang = ""
+			}
+			out = append(out, "```"+lang+"\n"+EscapeCode(strings.Join(code, "\n"))+"\n```")
+			blank = false
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			if !blank && len(out) > 0 {
+				out = append(out, "")
+			}
+			blank = true
+			continue
+		}
+		blank = false
+
+		if rendered, ok := r.renderLine(line); ok {
+			out = append(out, rendered)
+		}
+	}
+
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+
+	return strings.Join(out, "\n")
+}
+
+func isClosingFence(line string, fence string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == ""
+}
+
+// renderLine - renders single line outside of code blocks, returns false if line should be dropped
+func (r Renderer) renderLine(line string) (string, bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	indent := line[:len(line)-len(trimmed)]
+	trimmed = strings.TrimRight(trimmed, " ")
+
+	if strings.HasPrefix(trimmed, ">") {
+		// Telegram has no nested quotes, all levels are flattened
+		rest := strings.TrimLeft(trimmed, "> ")
+		if rest == "" {
+			return ">", true
+		}
+		rendered, ok := r.renderLine(rest)
+		if !ok {
+			return ">", true
+		}
+		return ">" + rendered, true
+	}
+
+	if m := headingRe.FindStringSubmatch(trimmed); m != nil {
+		if m[1] == "" {
+			return "", false
+		}
+		return "*" + r.renderInline(m[1], inline{bold: true}) + "*", true
+	}
+
+	if ruleRe.MatchString(trimmed) {
+		return "——————", true
+	}
+
+	if tableRuleRe.MatchString(trimmed) {
+		return "", false
+	}
+
+	if m := bulletRe.FindStringSubmatch(trimmed); m != nil {
+		return indent + r.renderListItem("•", m[1]), true
+	}
+
+	if m := orderedRe.FindStringSubmatch(trimmed); m != nil {
+		return indent + r.renderListItem(m[1]+"\\.", m[2]), true
+	}
+
+	return r.renderInline(trimmed, inline{}), true
+}
+
+func (r Renderer) renderListItem(marker string, text string) string {
+	if m := taskRe.FindStringSubmatch(text); m != nil {
+		marker = "☐"
+		if m[1] != " " {
+			marker = "☑"
+		}
+		text = m[2]
+	}
+
+	return marker + " " + r.renderInline(text, inline{})
+}
+
+// inline - formatting text is nested in, Telegram does not allow nesting entities of the same type
+type inline struct {
+	link   bool
+	bold   bool
+	italic bool
+	strike bool
+}
+
+// renderInline - renders inline formatting of text
+func (r Renderer) renderInline(s string, in inline) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		switch {
+		case c == '\\' && i+1 < len(s) && isPunct(s[i+1]):
+			b.WriteString(Escape(s[i+1 : i+2]))
+			i += 2
+			continue
+
+		case c == '`':
+			n := runLength(s, i, '`')
+			if j := findRun(s, i+n, '`', n); j >= 0 {
+				code := s[i+n : j]
+				if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' {
+					code = code[1 : len(code)-1]
+				}
+				b.WriteString("`" + EscapeCode(code) + "`")
+				i = j + n
+				continue
+			}
+			b.WriteString(Escape(s[i : i+n]))
+			i += n
+			continue
+
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			if text, url, end, ok := parseLink(s, i+1); ok {
+				if text == "" {
+					text = "image"
+				}
+				b.WriteString(r.renderLink("🖼 "+text, url, in))
+				i = end
+				continue
+			}
+
+		case c == '[':
+			if text, url, end, ok := parseLink(s, i); ok {
+				b.WriteString(r.renderLink(text, url, in))
+				i = end
+				continue
+			}
+
+		case c == '<':
+			if m := autolinkRe.FindStringSubmatch(s[i:]); m != nil {
+				if !in.link {
+					b.WriteString(Link(m[1], m[1]))
+				} else {
+					b.WriteString(Escape(m[1]))
+				}
+				i += len(m[0])
+				continue
+			}
+			if m := lineBreakRe.FindString(s[i:]); m != "" {
+				b.WriteString("\n")
+				i += len(m)
+				continue
+			}
+
+		case c == 'h' && !isWordByte(s, i-1) && (strings.HasPrefix(s[i:], "http://") || strings.HasPrefix(s[i:], "https://")):
+			// Bare URLs are linked by Telegram, they only need to be kept out of emphasis parsing
+			end := strings.IndexAny(s[i:], " <")
+			if end < 0 {
+				end = len(s) - i
+			}
+			b.WriteString(Escape(s[i : i+end]))
+			i += end
+			continue
+
+		case c == '*' || c == '_' || c == '~':
+			if rendered, end, ok := r.renderEmphasis(s, i, in); ok {
+				if strings.HasPrefix(rendered, "_") && endsWithMarker(b.String(), '_') {
+					// Telegram reads __ as underline, \r between adjacent italics is ignored by Telegram
+					b.WriteString("\r")
+				}
+				b.WriteString(rendered)
+				i = end
+				continue
+			}
+			n := runLength(s, i, c)
+			b.WriteString(Escape(s[i : i+n]))
+			i += n
+			continue
+
+		case c == '@' && !isWordByte(s, i-1):
+			if m := mentionRe.FindStringSubmatch(s[i:]); m != nil {
+				if !in.link && r.ProfileURL != "" {
+					b.WriteString(Link(m[0], r.ProfileURL+m[1]))
+				} else {
+					b.WriteString(Escape(m[0]))
+				}
+				i += len(m[0])
+				continue
+			}
+		}
+
+		b.WriteString(Escape(s[i : i+1]))
+		i++
+	}
+
+	return b.String()
+}
+
+// renderEmphasis - renders emphasis starting at i, returns false if delimiters at i do not open emphasis
+func (r Renderer) renderEmphasis(s string, i int, in inline) (string, int, bool) {
+	c := s[i]
+	n := runLength(s, i, c)
+	if n > 3 || c == '~' && n > 2 {
+		return "", 0, false
+	}
+
+	delim := s[i : i+n]
+	start := i + n
+	if start >= len(s) || s[start] == ' ' {
+		return "", 0, false
+	}
+	// Underscores inside words, e.g. snake_case, are not emphasis
+	if c == '_' && isWordByte(s, i-1) {
+		return "", 0, false
+	}
+
+	for j := start + 1; j+n <= len(s); j++ {
+		if s[j:j+n] != delim || s[j-1] == ' ' || s[j-1] == '\\' {
+			continue
+		}
+		if j+n < len(s) && s[j+n] == c {
+			// Longer run, not a matching closing delimiter
+			j += runLength(s, j, c) - 1
+			continue
+		}
+		if c == '_' && isWordByte(s, j+n) {
+			continue
+		}
+
+		var open, close string
+		inner := in
+		if c == '~' {
+			open, close = toggle(&inner.strike, "~")
+		} else {
+			if n != 1 {
+				open, close = toggle(&inner.bold, "*")
+			}
+			if n != 2 {
+				o, c := toggle(&inner.italic, "_")
+				open, close = open+o, c+close
+			}
+		}
+
+		return open + r.renderInline(s[start:j], inner) + close, j + n, true
+	}
+
+	return "", 0, false
+}
+
+// endsWithMarker - checks that rendered text ends with unescaped entity marker
+func endsWithMarker(s string, marker byte) bool {
+	if len(s) == 0 || s[len(s)-1] != marker {
+		return false
+	}
+
+	backslashes := 0
+	for i := len(s) - 2; i >= 0 && s[i] == '\\'; i-- {
+		backslashes++
+	}
+	return backslashes%2 == 0
+}
+
+// toggle - returns markers of entity unless text is already inside entity of the same type
+func toggle(inside *bool, marker string) (string, string) {
+	if *inside {
+		return "", ""
+	}
+	*inside = true
+	return marker, marker
+}
+
+func (r Renderer) renderLink(text string, url string, in inline) string {
+	if in.link {
+		return r.renderInline(text, in)
+	}
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "mailto:") {
+		// Telegram rejects relative URLs, only link text is shown
+		return r.renderInline(text, in)
+	}
+
+	in.link = true
+	return "[" + r.renderInline(text, in) + "](" + EscapeURL(url) + ")"
+}
+
+// parseLink - parses [text](url "title") starting at i, returns index after the link
+func parseLink(s string, i int) (string, string, int, bool) {
+	depth := 0
+	textEnd := -1
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '[':
+			depth++
+		case ']':
+			depth--
+		}
+		if depth == 0 {
+			textEnd = j
+			break
+		}
+	}
+	if textEnd < 0 || textEnd+1 >= len(s) || s[textEnd+1] != '(' {
+		return "", "", 0, false
+	}
+
+	depth = 0
+	urlEnd := -1
+	for j := textEnd + 1; j < len(s); j++ {
+		if s[j] == '(' {
+			depth++
+		} else if s[j] == ')' {
+			depth--
+		}
+		if depth == 0 {
+			urlEnd = j
+			break
+		}
+	}
+	if urlEnd < 0 {
+		return "", "", 0, false
+	}
+
+	url := strings.TrimSpace(s[textEnd+2 : urlEnd])
+	if k := strings.IndexAny(url, " \t"); k >= 0 {
+		// Drop link title
+		url = url[:k]
+	}
+	url = strings.TrimSuffix(strings.TrimPrefix(url, "<"), ">")
+	if url == "" {
+		return "", "", 0, false
+	}
+
+	return s[i+1 : textEnd], url, urlEnd + 1, true
+}
+
+func runLength(s string, i int, c byte) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == c {
+		n++
+	}
+	return n
+}
+
+// findRun - finds run of exactly n bytes c at or after i
+func findRun(s string, i int, c byte, n int) int {
+	for i < len(s) {
+		if s[i] != c {
+			i++
+			continue
+		}
+		m := runLength(s, i, c)
+		if m == n {
+			return i
+		}
+		i += m
+	}
+	return -1
+}
+
+func isWordByte(s string, i int) bool {
+	if i < 0 || i >= len(s) {
+		return false
+	}
+	c := s[i]
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+func isPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
@@ -0,0 +1,76 @@
+package markdown
+
+import "testing"
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"bold", "**bold**", "*bold*"},
+		{"italic", "_italic_", "_italic_"},
+		{"nested italic in bold", "**bold _italic_ bold**", "*bold _italic_ bold*"},
+		{"nested bold in italic", "_italic **bold** italic_", "_italic *bold* italic_"},
+		{"bold italic", "***both***", "*_both_*"},
+		{"same entity is not nested", "**bold __also bold__**", "*bold also bold*"},
+		{"strike", "~~strike~~", "~strike~"},
+		{"snake_case", "snake_case_name", "snake\\_case\\_name"},
+		{"__init__.py", "__init__.py", "*init*\\.py"},
+		{"__init__.py in text", "run __init__.py now", "run *init*\\.py now"},
+		{"escaped underscores", "\\_not\\_", "\\_not\\_"},
+		{"unclosed emphasis", "2 * 3 = 6", "2 \\* 3 \\= 6"},
+		{"link", "[text](https://example.com)", "[text](https://example.com)"},
+		{"link with parentheses", "[Go](https://en.wikipedia.org/wiki/Go_(programming_language))", "[Go](https://en.wikipedia.org/wiki/Go_(programming_language\\))"},
+		{"relative link", "[docs](docs/README.md)", "docs"},
+		{"code span", "`a_b*c`", "`a_b*c`"},
+		{"code span with backtick", "``code with ` tick``", "`code with \\` tick`"},
+		{"code span is not emphasis", "`_not italic_`", "`_not italic_`"},
+		{"adjacent italics", "*italic*_under_", "_italic_\r_under_"},
+		{"adjacent italic and bold", "_italic_**bold**", "_italic_*bold*"},
+		{"three adjacent italics", "*a*_b_*c*", "_a_\r_b_\r_c_"},
+		{"italic next to escaped underscore", "*a*\\_", "_a_\\_"},
+		{"mention", "@andreyst", "[@andreyst](https://github.com/andreyst)"},
+		{"mention with dash", "thanks @user-name, merged", "thanks [@user\\-name](https://github.com/user-name), merged"},
+		{"email is not mention", "email a@b.com", "email a@b\\.com"},
+		{"fenced block with backtick and backslash", "```\nfmt.Println(`a\\b`)\n```", "```\nfmt.Println(\\`a\\\\b\\`)\n```"},
+		{"fenced block with language", "```go\nx := \"\\\\\"\n```", "```go\nx := \"\\\\\\\\\"\n```"},
+		{"tilde fence containing backtick fence", "~~~\nuse ``` here\n~~~", "```\nuse \\`\\`\\` here\n```"},
+		{"unclosed fence", "```\nnever closed `x`", "```\nnever closed \\`x\\`\n```"},
+		{"nested list", "- one\n  - nested\n    - deeper\n- two", "• one\n  • nested\n    • deeper\n• two"},
+		{"star list", "* star item", "• star item"},
+		{"ordered list", "1. first\n2. second\n   1. inner", "1\\. first\n2\\. second\n   1\\. inner"},
+		{"task list", "- [ ] todo\n- [x] done\n- [X] Done too", "☐ todo\n☑ done\n☑ Done too"},
+		{"multi-line quote", "> first line\n> second _line_\n>\n> after blank", ">first line\n>second _line_\n>\n>after blank"},
+		{"heading with special characters", "# Title (v1.2) [draft]!", "*Title \\(v1\\.2\\) \\[draft\\]\\!*"},
+		{"heading with emphasis", "## a_b *c*", "*a\\_b _c_*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Renderer{ProfileURL: GithubProfileURL}).Render(tt.src); got != tt.want {
+				t.Errorf("Render(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderMentionProfileURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		profileURL string
+		want       string
+	}{
+		{"Github", GithubProfileURL, "[@andreyst](https://github.com/andreyst)"},
+		{"Gitea", "https://gitea.example.com/", "[@andreyst](https://gitea.example.com/andreyst)"},
+		{"no profiles", "", "@andreyst"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Renderer{ProfileURL: tt.profileURL}).Render("@andreyst"); got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package markdown
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// MaxLength - max length of Telegram message text
+const MaxLength = 4096
+
+// Room for part numbers, e.g. "\n\(12/12\)"
+const partLabelLength = 16
+
+// RenderParts - renders MarkdownV2 header, Github Markdown body and MarkdownV2 footer in at most maxParts messages
+// fitting Telegram limits. Body is split between lines where possible, every part is valid MarkdownV2
+// on its own, footer ends the last part. If body does not fit, the last part is truncated and links to moreURL
+// on tracker with trackerName, e.g. "Github"
+func (r Renderer) RenderParts(header string, body string, footer string, trackerName string, moreURL string, maxParts int) []string {
+	if maxParts < 1 {
+		maxParts = 1
+	}
+	budget := MaxLength - partLabelLength
+	more := "\n… " + Link("read more on "+trackerName, moreURL)
+
+	lines := strings.Split(strings.Replace(body, "\r\n", "\n", -1), "\n")
+	var parts []string
+	prefix := header
+	carry := ""
+	for len(lines) > 0 {
+		last := len(parts) == maxParts-1
+
+		n := r.fitLines(prefix, carry, lines, footer, budget)
+		if n == len(lines) {
+			parts = append(parts, prefix+r.Render(withCarry(carry, lines))+footer)
+			lines = nil
+			break
+		}
+
+		suffix := ""
+		if !last {
+			// Footer goes to the last part only
+			n = r.fitLines(prefix, carry, lines, "", budget)
+			if n == len(lines) {
+				// Body fits without footer only, the last line moves to the next part along with footer
+				n--
+			}
+		} else {
+			suffix = more + footer
+			n = r.fitLines(prefix, carry, lines, suffix, budget)
+		}
+
+		chunk := lines[:n]
+		rest := lines[n:]
+		if n == 0 {
+			// Single line does not fit, it is cut between runes
+			head, tail := r.cutLine(prefix, carry, lines[0], suffix, budget)
+			chunk = []string{head}
+			rest = append([]string{tail}, lines[1:]...)
+		}
+
+		parts = append(parts, prefix+r.Render(withCarry(carry, chunk))+suffix)
+		if last {
+			lines = nil
+			break
+		}
+
+		carry = openFenceAfter(carry, chunk)
+		lines = rest
+		prefix = ""
+	}
+	if len(parts) == 0 {
+		parts = append(parts, header+footer)
+	}
+
+	if len(parts) > 1 {
+		for i := range parts {
+			parts[i] += fmt.Sprintf("\n\\(%d/%d\\)", i+1, len(parts))
+		}
+	}
+
+	return parts
+}
+
+// Length - returns length of text as counted by Telegram
+func Length(text string) int {
+	return len(utf16.Encode([]rune(text)))
+}
+
+// fitLines - returns how many lines fit into budget after prefix and before suffix
+func (r Renderer) fitLines(prefix string, carry string, lines []string, suffix string, budget int) int {
+	fits := func(n int) bool {
+		return Length(prefix+r.Render(withCarry(carry, lines[:n]))+suffix) <= budget
+	}
+
+	// Rendered length grows with number of lines, so the largest fitting number is found with binary search
+	lo, hi := 0, len(lines)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if fits(mid) {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+
+	return lo
+}
+
+// cutLine - cuts line into the longest fitting head and the rest
+func (r Renderer) cutLine(prefix string, carry string, line string, suffix string, budget int) (string, string) {
+	runes := []rune(line)
+	fits := func(n int) bool {
+		return Length(prefix+r.Render(withCarry(carry, []string{string(runes[:n])}))+suffix) <= budget
+	}
+
+	lo, hi := 0, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if fits(mid) {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	if lo == 0 {
+		// Nothing fits after prefix, e.g. very long header, progress is made anyway
+		lo = 1
+	}
+
+	return string(runes[:lo]), string(runes[lo:])
+}
+
+func withCarry(carry string, lines []string) string {
+	text := strings.Join(lines, "\n")
+	if carry == "" {
+		return text
+	}
+	return carry + "\n" + text
+}
+
+// openFenceAfter - returns opening line of code block which lines end inside of, or empty string
+func openFenceAfter(carry string, lines []string) string {
+	open := carry
+	fence := ""
+	if m := fenceRe.FindStringSubmatch(carry); m != nil {
+		fence = m[2]
+	}
+
+	for _, line := range lines {
+		if open != "" {
+			if isClosingFence(line, fence) {
+				open, fence = "", ""
+			}
+			continue
+		}
+		if m := fenceRe.FindStringSubmatch(line); m != nil {
+			open, fence = line, m[2]
+		}
+	}
+
+	return open
+}
@@ -0,0 +1,139 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRenderParts(t *testing.T) {
+	codeLines := strings.Repeat("fmt.Println(\"hello\")\n", 400)
+	textLines := strings.Repeat("Line of text\n", 1000)
+
+	tests := []struct {
+		name      string
+		body      string
+		maxParts  int
+		wantParts int
+		wantMore  bool
+	}{
+		{"short body", "Hello, **world**", 3, 1, false},
+		{"empty body", "", 3, 1, false},
+		{"long body split between lines", textLines, 5, 4, false},
+		{"fenced code block carried across parts", "```go\n" + codeLines + "```\nafter", 5, 3, false},
+		{"truncated at max parts", textLines, 2, 2, true},
+		{"single line longer than limit", strings.Repeat("word ", 2000), 5, 3, false},
+		{"single line truncated", strings.Repeat("word ", 2000), 1, 1, true},
+		{"multibyte line", strings.Repeat("слово ", 2000), 5, 3, false},
+		{"surrogate pairs", strings.Repeat("😀", 5000), 5, 3, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := Renderer{}.RenderParts("*Header*\n", tt.body, "\n_footer_", "Gitea", "https://gitea.example.com/team/bridge/issues/7", tt.maxParts)
+			if len(parts) != tt.wantParts {
+				t.Fatalf("RenderParts() returned %d parts, want %d", len(parts), tt.wantParts)
+			}
+
+			if !strings.HasPrefix(parts[0], "*Header*\n") {
+				t.Errorf("first part %q does not start with header", head(parts[0]))
+			}
+			last := parts[len(parts)-1]
+			more := strings.Contains(last, "read more on Gitea](https://gitea.example.com/team/bridge/issues/7)")
+			if more != tt.wantMore {
+				t.Errorf("last part links to tracker = %v, want %v", more, tt.wantMore)
+			}
+			for i, part := range parts {
+				if l := Length(part); l > MaxLength {
+					t.Errorf("part %d is %d characters long, over limit", i+1, l)
+				}
+				if !utf8.ValidString(part) {
+					t.Errorf("part %d is cut inside a rune", i+1)
+				}
+				if n := strings.Count(part, "```"); n%2 != 0 {
+					t.Errorf("part %d has %d code fences, code block is not closed", i+1, n)
+				}
+				if hasFooter := strings.Contains(part, "_footer_"); hasFooter != (i == len(parts)-1) {
+					t.Errorf("part %d has footer = %v", i+1, hasFooter)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderPartsKeepsCodeBlockLanguage(t *testing.T) {
+	parts := Renderer{}.RenderParts("", "```go\n"+strings.Repeat("x := 1\n", 1000)+"```", "", "Github", "https://example.com", 5)
+	if len(parts) < 2 {
+		t.Fatalf("RenderParts() returned %d parts, want several", len(parts))
+	}
+	for i, part := range parts {
+		if !strings.HasPrefix(part, "```go\n") {
+			t.Errorf("part %d %q does not reopen code block", i+1, head(part))
+		}
+	}
+}
+
+func TestFitLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		carry  string
+		lines  []string
+		suffix string
+		budget int
+		want   int
+	}{
+		{"all fit", "", "", []string{"aaa", "bbb", "ccc"}, "", 11, 3},
+		{"some fit", "", "", []string{"aaa", "bbb", "ccc"}, "", 7, 2},
+		{"prefix and suffix count", "p", "", []string{"aaa", "bbb", "ccc"}, "s", 7, 1},
+		{"none fit", "", "", []string{"aaaa"}, "", 3, 0},
+		{"escaping counts", "", "", []string{"a.b", "c"}, "", 4, 1},
+		{"multibyte counted in UTF-16", "", "", []string{"ааа", "ббб"}, "", 7, 2},
+		{"surrogate pairs counted twice", "", "", []string{"😀😀", "😀"}, "", 6, 1},
+		{"carried fence counts", "", "```", []string{"a"}, "", 8, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Renderer{}).fitLines(tt.prefix, tt.carry, tt.lines, tt.suffix, tt.budget); got != tt.want {
+				t.Errorf("fitLines(%q, %q, %q, %q, %d) = %d, want %d", tt.prefix, tt.carry, tt.lines, tt.suffix, tt.budget, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCutLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		line     string
+		budget   int
+		wantHead string
+		wantTail string
+	}{
+		{"ascii", "", "abcdef", 4, "abcd", "ef"},
+		{"after prefix", "pp", "abcdef", 4, "ab", "cdef"},
+		{"multibyte", "", "абвгд", 3, "абв", "гд"},
+		{"inside surrogate pair", "", "😀😀😀", 5, "😀😀", "😀"},
+		{"surrogate pair after ascii", "", "a😀b", 2, "a", "😀b"},
+		{"nothing fits", "long prefix", "😀b", 3, "😀", "b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			head, tail := Renderer{}.cutLine(tt.prefix, "", tt.line, "", tt.budget)
+			if head != tt.wantHead || tail != tt.wantTail {
+				t.Errorf("cutLine(%q, %q, %d) = %q, %q, want %q, %q", tt.prefix, tt.line, tt.budget, head, tail, tt.wantHead, tt.wantTail)
+			}
+		})
+	}
+}
+
+// head - returns beginning of part for error messages
+func head(part string) string {
+	runes := []rune(part)
+	if len(runes) > 40 {
+		return string(runes[:40]) + "…"
+	}
+	return part
+}
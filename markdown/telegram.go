@@ -0,0 +1,222 @@
+package markdown
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// FromTelegram - converts Telegram message text with formatting entities to Github flavored Markdown
+// Plain text is kept as is, so that Markdown typed by users still works on Github
+func FromTelegram(text string, entities []tgbotapi.MessageEntity) string {
+	// Entity offsets are counted in UTF-16 code units
+	units := utf16.Encode([]rune(text))
+
+	var spans []span
+	for _, entity := range entities {
+		if s, ok := newSpan(entity, units); ok {
+			spans = append(spans, s)
+		}
+	}
+	spans = nestSpans(spans, units)
+
+	var b strings.Builder
+	var open []span
+	quotes := 0
+	next := 0
+	for pos := 0; pos <= len(units); pos++ {
+		for len(open) > 0 && open[len(open)-1].end <= pos {
+			s := open[len(open)-1]
+			open = open[:len(open)-1]
+			b.WriteString(s.close)
+			if s.quote {
+				quotes--
+			}
+		}
+		for next < len(spans) && spans[next].start == pos {
+			s := spans[next]
+			next++
+			if s.quote {
+				quotes++
+			}
+			if s.block && pos > 0 && units[pos-1] != '\n' {
+				b.WriteString("\n")
+			}
+			b.WriteString(s.open)
+			open = append(open, s)
+		}
+		if pos == len(units) {
+			break
+		}
+
+		r, size := decodeRune(units, pos)
+		b.WriteRune(r)
+		if r == '\n' && quotes > 0 {
+			b.WriteString("> ")
+		}
+		pos += size - 1
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString(open[i].close)
+	}
+
+	return b.String()
+}
+
+var githubReplacer = strings.NewReplacer(
+	"\\", "\\\\",
+	"`", "\\`",
+	"*", "\\*",
+	"_", "\\_",
+	"[", "\\[",
+	"]", "\\]",
+	"<", "\\<",
+	">", "\\>",
+	"#", "\\#",
+	"~", "\\~",
+	"|", "\\|",
+)
+
+// EscapeGithub - escapes text, e.g. Telegram caption, to be shown literally in Github Markdown
+func EscapeGithub(s string) string {
+	return githubReplacer.Replace(s)
+}
+
+// nestSpans - sorts spans outer first and shortens partially overlapping ones, so that they nest properly.
+// Telegram entities nest, but trimming whitespace from emphasis can make them overlap:
+// emphasis overlapping link start ends before the link, other overlapping spans end with the outer span
+func nestSpans(spans []span, units []uint16) []span {
+	sortSpans(spans)
+
+	for i := 0; i < len(spans); i++ {
+		for j := i + 1; j < len(spans) && spans[j].start < spans[i].end; j++ {
+			if spans[j].end <= spans[i].end {
+				continue
+			}
+			if spans[j].link && spans[i].trim {
+				spans[i].end = spans[j].start
+				spans[i].trimSpace(units)
+			} else {
+				spans[j].end = spans[i].end
+				spans[j].trimSpace(units)
+			}
+		}
+	}
+
+	nested := spans[:0]
+	for _, s := range spans {
+		if s.start < s.end {
+			nested = append(nested, s)
+		}
+	}
+	sortSpans(nested)
+
+	return nested
+}
+
+func sortSpans(spans []span) {
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].end > spans[j].end
+	})
+}
+
+type span struct {
+	start int
+	end   int
+	open  string
+	close string
+	quote bool
+	// Block spans start on a new line
+	block bool
+	link  bool
+	// Markdown emphasis must not start or end with whitespace
+	trim bool
+}
+
+// trimSpace - moves span boundaries past whitespace, empty spans have start == end
+func (s *span) trimSpace(units []uint16) {
+	if !s.trim {
+		return
+	}
+	for s.start < s.end && isSpace(units[s.start]) {
+		s.start++
+	}
+	for s.end > s.start && isSpace(units[s.end-1]) {
+		s.end--
+	}
+}
+
+func newSpan(entity tgbotapi.MessageEntity, units []uint16) (span, bool) {
+	s := span{start: entity.Offset, end: entity.Offset + entity.Length}
+	if s.start < 0 || s.end > len(units) || s.start >= s.end {
+		return s, false
+	}
+
+	switch entity.Type {
+	case "bold", "italic", "underline", "strikethrough", "code":
+		s.trim = true
+		s.trimSpace(units)
+		if s.start == s.end {
+			return s, false
+		}
+	}
+
+	switch entity.Type {
+	case "bold":
+		s.open, s.close = "**", "**"
+	case "italic":
+		s.open, s.close = "*", "*"
+	case "underline":
+		s.open, s.close = "<ins>", "</ins>"
+	case "strikethrough":
+		s.open, s.close = "~~", "~~"
+	case "code":
+		s.open, s.close = "`", "`"
+		if containsUnit(units[s.start:s.end], '`') {
+			s.open, s.close = "`` ", " ``"
+		}
+	case "pre":
+		s.open, s.close = "```\n", "\n```\n"
+		s.block = true
+	case "text_link":
+		s.open, s.close = "[", "]("+destinationReplacer.Replace(entity.URL)+")"
+		s.link = true
+	case "blockquote":
+		s.open, s.close = "> ", "\n"
+		s.quote = true
+		s.block = true
+	default:
+		// URLs, mentions, hashtags and the like are plain text on Github too
+		return s, false
+	}
+
+	return s, true
+}
+
+// destinationReplacer - escapes characters ending link destination in Github Markdown
+var destinationReplacer = strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`)
+
+func decodeRune(units []uint16, pos int) (rune, int) {
+	if utf16.IsSurrogate(rune(units[pos])) && pos+1 < len(units) {
+		return utf16.DecodeRune(rune(units[pos]), rune(units[pos+1])), 2
+	}
+	return rune(units[pos]), 1
+}
+
+func isSpace(u uint16) bool {
+	return u == ' ' || u == '\n' || u == '\t'
+}
+
+func containsUnit(units []uint16, u uint16) bool {
+	for _, v := range units {
+		if v == u {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package markdown
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+func TestFromTelegram(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		entities []tgbotapi.MessageEntity
+		want     string
+	}{
+		{
+			name: "plain text",
+			text: "snake_case *stays*",
+			want: "snake_case *stays*",
+		},
+		{
+			name:     "bold with trailing space",
+			text:     "bold text",
+			entities: []tgbotapi.MessageEntity{{Type: "bold", Offset: 0, Length: 5}},
+			want:     "**bold** text",
+		},
+		{
+			name: "nested",
+			text: "bold italic",
+			entities: []tgbotapi.MessageEntity{
+				{Type: "bold", Offset: 0, Length: 11},
+				{Type: "italic", Offset: 5, Length: 6},
+			},
+			want: "**bold *italic***",
+		},
+		{
+			name: "bold overlapping link after trimming",
+			text: "a b c d",
+			entities: []tgbotapi.MessageEntity{
+				{Type: "bold", Offset: 0, Length: 6},
+				{Type: "text_link", Offset: 4, Length: 3, URL: "https://example.com"},
+			},
+			want: "**a b** [c d](https://example.com)",
+		},
+		{
+			name: "italic overlapping end of bold",
+			text: "one two three",
+			entities: []tgbotapi.MessageEntity{
+				{Type: "bold", Offset: 0, Length: 8},
+				{Type: "italic", Offset: 4, Length: 9},
+			},
+			want: "**one *two*** three",
+		},
+		{
+			name:     "span ending with text",
+			text:     "end",
+			entities: []tgbotapi.MessageEntity{{Type: "strikethrough", Offset: 0, Length: 3}},
+			want:     "~~end~~",
+		},
+		{
+			name:     "link with parenthesis and backslash",
+			text:     "link",
+			entities: []tgbotapi.MessageEntity{{Type: "text_link", Offset: 0, Length: 4, URL: `https://x.com/a)b\c`}},
+			want:     `[link](https://x.com/a\)b\\c)`,
+		},
+		{
+			name:     "emoji before entity",
+			text:     "👍 ok",
+			entities: []tgbotapi.MessageEntity{{Type: "code", Offset: 3, Length: 2}},
+			want:     "👍 `ok`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromTelegram(tt.text, tt.entities); got != tt.want {
+				t.Errorf("FromTelegram(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeGithub(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain", "screenshot of bug", "screenshot of bug"},
+		{"emphasis", "*not bold* and _not italic_", `\*not bold\* and \_not italic\_`},
+		{"code and link", "`x` [a](b)", "\\`x\\` \\[a\\](b)"},
+		{"backslash", `C:\path`, `C:\\path`},
+		{"heading and quote", "# not heading\n> not quote", "\\# not heading\n\\> not quote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EscapeGithub(tt.text); got != tt.want {
+				t.Errorf("EscapeGithub(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package messenger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Formats of outgoing message text
+const (
+	// FormatPlain - text is shown as is
+	FormatPlain = iota
+	// FormatMarkdown - text is Telegram MarkdownV2, which other messengers convert to their markup
+	FormatMarkdown
+)
+
+// Messenger - chat service issues are bridged to
+// Chats, messages and users are identified with numbers, messengers with other IDs map them to numbers
+type Messenger interface {
+	// Name - messenger name, e.g. "telegram", also used as queue item source of its updates
+	Name() string
+	// UserName - user name of bot, used to tell commands addressed to it
+	UserName() string
+
+	// Send - posts message and returns its ID
+	Send(ctx context.Context, msg Outgoing) (int64, error)
+	// Edit - replaces text of posted message, editing message to the same text is not an error
+	Edit(ctx context.Context, messageID int64, msg Outgoing) error
+	// Delete - deletes message
+	Delete(ctx context.Context, chatID int64, messageID int64) error
+	// Download - opens file attached to incoming message, returns its contents and file name
+	Download(ctx context.Context, fileID string) (io.ReadCloser, string, error)
+
+	// Receive - polls for updates starting from offset until ctx is cancelled, passes raw payload
+	// of every update with its ID and offset to continue from to save, which should store them durably.
+	// Messengers pushing updates to webhook return ErrPushOnly
+	Receive(ctx context.Context, offset int64, save func(id string, payload []byte, next int64) error) error
+	// Decode - converts raw update payload, received by Receive or webhook, to update,
+	// returns false for updates bridge does not handle
+	Decode(payload []byte) (Update, bool, error)
+}
+
+// Topics - messenger with topics in chats, where every issue can get its own topic
+type Topics interface {
+	// CreateTopic - creates topic in chat and returns its thread ID
+	CreateTopic(ctx context.Context, chatID int64, name string) (int64, error)
+	// SetTopicClosed - closes or reopens topic, closing closed topic or reopening open one is not an error
+	SetTopicClosed(ctx context.Context, chatID int64, threadID int64, closed bool) error
+}
+
+// ErrPushOnly - returned by Receive of messengers which can not be polled for updates
+var ErrPushOnly = errors.New("messenger pushes updates to webhook only")
+
+// Outgoing - message to post
+type Outgoing struct {
+	ChatID int64
+	Text   string
+	// FormatPlain or FormatMarkdown
+	Format int
+	// Message to reply to, 0 for none
+	ReplyTo int64
+	// Thread, e.g. forum topic, to post message to, 0 for top level of chat
+	Thread int64
+}
+
+// RateLimitError - messenger refused request because of rate limits, request can be repeated after delay
+type RateLimitError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%v (retry after %v)", e.Err, e.RetryAfter)
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
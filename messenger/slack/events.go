@@ -0,0 +1,182 @@
+package slack
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/andreyst/tracker-messenger-bridge/messenger"
+)
+
+// envelope - Events API request
+type envelope struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	EventID   string `json:"event_id"`
+	Event     event  `json:"event"`
+}
+
+// event - message event, edited message is in Message
+type event struct {
+	Type        string `json:"type"`
+	Subtype     string `json:"subtype"`
+	Channel     string `json:"channel"`
+	ChannelType string `json:"channel_type"`
+	User        string `json:"user"`
+	BotID       string `json:"bot_id"`
+	Text        string `json:"text"`
+	TS          string `json:"ts"`
+	ThreadTS    string `json:"thread_ts"`
+	Files       []struct {
+		Name               string `json:"name"`
+		Title              string `json:"title"`
+		Size               int    `json:"size"`
+		URLPrivateDownload string `json:"url_private_download"`
+	} `json:"files"`
+	Message *event `json:"message"`
+}
+
+// VerifySignature - checks that request was signed by Slack with signing secret of app
+func VerifySignature(secret string, header http.Header, body []byte) bool {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	if timestamp == "" {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature")))
+}
+
+// IsFresh - checks that request was signed recently, so that it is not a replay of old one
+func IsFresh(header http.Header, now time.Time) bool {
+	timestamp, err := strconv.ParseInt(header.Get("X-Slack-Request-Timestamp"), 10, 64)
+	if err != nil {
+		return false
+	}
+
+	age := now.Sub(time.Unix(timestamp, 0))
+	return age < 5*time.Minute && age > -5*time.Minute
+}
+
+// Challenge - returns challenge to answer URL verification request with, false for other requests
+func Challenge(body []byte) (string, bool) {
+	var e envelope
+	if err := json.Unmarshal(body, &e); err != nil || e.Type != "url_verification" {
+		return "", false
+	}
+
+	return e.Challenge, true
+}
+
+// EventID - returns ID of event in request, empty for requests without event
+func EventID(body []byte) string {
+	var e envelope
+	if err := json.Unmarshal(body, &e); err != nil {
+		return ""
+	}
+
+	return e.EventID
+}
+
+// Decode - converts new and edited messages in configured channels,
+// messages of bots, including own ones, and other events are skipped.
+// Direct messages are skipped too, as bot commands are not supported on Slack, see README
+func (c *Client) Decode(payload []byte) (messenger.Update, bool, error) {
+	var e envelope
+	err := json.Unmarshal(payload, &e)
+	if err != nil {
+		return messenger.Update{}, false, err
+	}
+	if e.Type != "event_callback" || e.Event.Type != "message" {
+		return messenger.Update{}, false, nil
+	}
+
+	chatID, ok := c.chats[e.Event.Channel]
+	if !ok {
+		return messenger.Update{}, false, nil
+	}
+
+	u := messenger.Update{ID: e.EventID}
+	switch e.Event.Subtype {
+	case "", "file_share":
+		u.Message, ok, err = c.convertMessage(chatID, e.Event)
+	case "message_changed":
+		if e.Event.Message == nil {
+			return messenger.Update{}, false, nil
+		}
+		e.Event.Message.ChannelType = e.Event.ChannelType
+		u.EditedMessage, ok, err = c.convertMessage(chatID, *e.Event.Message)
+	default:
+		return messenger.Update{}, false, nil
+	}
+	if err != nil || !ok {
+		return messenger.Update{}, false, err
+	}
+
+	return u, true, nil
+}
+
+func (c *Client) convertMessage(chatID int64, e event) (*messenger.Message, bool, error) {
+	if e.BotID != "" || e.User == "" {
+		return nil, false, nil
+	}
+
+	id, err := parseTS(e.TS)
+	if err != nil {
+		return nil, false, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	userName, err := c.lookupUserName(ctx, e.User)
+	if err != nil {
+		return nil, false, err
+	}
+	markdown, err := c.toMarkdown(ctx, e.Text)
+	if err != nil {
+		return nil, false, err
+	}
+
+	m := &messenger.Message{
+		ID:       id,
+		ChatID:   chatID,
+		Private:  e.ChannelType == "im",
+		Text:     unescape(e.Text),
+		Markdown: markdown,
+	}
+	if userID, err := strconv.ParseInt(e.User, 36, 64); err == nil {
+		m.From = &messenger.User{ID: int(userID), UserName: userName}
+	}
+	for _, f := range e.Files {
+		label := f.Title
+		if label == "" {
+			label = f.Name
+		}
+		m.Attachments = append(m.Attachments, messenger.Attachment{
+			FileID: f.URLPrivateDownload,
+			Name:   f.Name,
+			Label:  label,
+			Size:   f.Size,
+		})
+	}
+	// Slack has no replies, messages in thread are replies to its parent
+	if e.ThreadTS != "" && e.ThreadTS != e.TS {
+		parent, err := parseTS(e.ThreadTS)
+		if err != nil {
+			return nil, false, err
+		}
+		m.ReplyTo = &messenger.Message{ID: parent, ChatID: chatID}
+	}
+
+	return m, true, nil
+}
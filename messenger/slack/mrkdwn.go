@@ -0,0 +1,166 @@
+package slack
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+var escapeReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+var unescapeReplacer = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&")
+
+// escape - escapes plain text to be shown literally in Slack
+func escape(s string) string {
+	return escapeReplacer.Replace(s)
+}
+
+// unescape - returns plain text of Slack message text with entities
+func unescape(s string) string {
+	return unescapeReplacer.Replace(s)
+}
+
+// FromMarkdownV2 - converts Telegram MarkdownV2, which messages are rendered to, to Slack mrkdwn
+// Underline and spoilers have no mrkdwn equivalent and are shown as plain text
+func FromMarkdownV2(s string) string {
+	var out strings.Builder
+	lineStart := true
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			i++
+			out.WriteString(escape(s[i : i+1]))
+		case strings.HasPrefix(s[i:], "```"):
+			end := strings.Index(s[i+3:], "```")
+			if end < 0 {
+				end = len(s) - i - 3
+			}
+			code := s[i+3 : i+3+end]
+			// Language is on the opening line, mrkdwn has no highlighting
+			if nl := strings.IndexByte(code, '\n'); nl >= 0 {
+				code = code[nl+1:]
+			}
+			out.WriteString("```" + escape(unescapeCode(code)) + "```")
+			i += 3 + end + 2
+		case c == '`':
+			end := indexUnescaped(s[i+1:], '`')
+			if end < 0 {
+				end = len(s) - i - 1
+			}
+			out.WriteString("`" + escape(unescapeCode(s[i+1:i+1+end])) + "`")
+			i += end + 1
+		case c == '[':
+			text, url, n, ok := parseLink(s[i:])
+			if !ok {
+				out.WriteString(escape("["))
+				break
+			}
+			out.WriteString("<" + url + "|" + FromMarkdownV2(text) + ">")
+			i += n - 1
+		case strings.HasPrefix(s[i:], "__"), strings.HasPrefix(s[i:], "||"):
+			i++
+		case c == '>' && lineStart:
+			out.WriteByte('>')
+		default:
+			out.WriteString(escape(s[i : i+1]))
+		}
+		lineStart = i < len(s) && s[i] == '\n'
+	}
+
+	return out.String()
+}
+
+// parseLink - parses MarkdownV2 link at the start of s, returns its text, URL and length
+func parseLink(s string) (string, string, int, bool) {
+	closing := indexUnescaped(s[1:], ']')
+	if closing < 0 || !strings.HasPrefix(s[closing+2:], "(") {
+		return "", "", 0, false
+	}
+	text := s[1 : closing+1]
+	rest := s[closing+3:]
+	end := indexUnescaped(rest, ')')
+	if end < 0 {
+		return "", "", 0, false
+	}
+	url := strings.NewReplacer(`\)`, ")", `\\`, `\`).Replace(rest[:end])
+
+	return text, url, closing + 3 + end + 1, true
+}
+
+// indexUnescaped - returns index of the first c in s not escaped with backslash, -1 if there is none
+func indexUnescaped(s string, c byte) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case c:
+			return i
+		}
+	}
+
+	return -1
+}
+
+func unescapeCode(s string) string {
+	return strings.NewReplacer("\\`", "`", `\\`, `\`).Replace(s)
+}
+
+var (
+	codeRe    = regexp.MustCompile("(?s)```.*?```|`[^`\n]*`")
+	angleRe   = regexp.MustCompile(`<([^<>|]+)(?:\|([^<>]*))?>`)
+	boldRe    = regexp.MustCompile(`(^|[\s(])\*([^*\n]+)\*`)
+	italicRe  = regexp.MustCompile(`(^|[\s(])_([^_\n]+)_`)
+	strikeRe  = regexp.MustCompile(`(^|[\s(])~([^~\n]+)~`)
+	mentionRe = regexp.MustCompile(`^@([A-Z0-9]+)$`)
+)
+
+// toMarkdown - converts Slack mrkdwn to Github Markdown, user mentions are replaced with user names
+func (c *Client) toMarkdown(ctx context.Context, s string) (string, error) {
+	var out strings.Builder
+	var err error
+	last := 0
+	for _, loc := range codeRe.FindAllStringIndex(s, -1) {
+		out.WriteString(c.convertText(ctx, s[last:loc[0]], &err))
+		out.WriteString(unescape(s[loc[0]:loc[1]]))
+		last = loc[1]
+	}
+	out.WriteString(c.convertText(ctx, s[last:], &err))
+
+	return strings.TrimSpace(out.String()), err
+}
+
+// convertText - converts mrkdwn outside code, the first failed user lookup is saved to err
+func (c *Client) convertText(ctx context.Context, s string, err *error) string {
+	s = boldRe.ReplaceAllString(s, "$1**$2**")
+	s = italicRe.ReplaceAllString(s, "$1*$2*")
+	s = strikeRe.ReplaceAllString(s, "$1~~$2~~")
+
+	s = angleRe.ReplaceAllStringFunc(s, func(token string) string {
+		m := angleRe.FindStringSubmatch(token)
+		target, label := m[1], m[2]
+		switch {
+		case mentionRe.MatchString(target):
+			name, lookupErr := c.lookupUserName(ctx, target[1:])
+			if lookupErr != nil {
+				if *err == nil {
+					*err = lookupErr
+				}
+				return target
+			}
+			return "@" + name
+		case strings.HasPrefix(target, "#"):
+			if label != "" {
+				return "#" + label
+			}
+			return target
+		case strings.HasPrefix(target, "!"):
+			return "@" + strings.TrimPrefix(target, "!")
+		case label != "":
+			return "[" + label + "](" + target + ")"
+		}
+		return target
+	})
+
+	return unescape(s)
+}
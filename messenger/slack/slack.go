@@ -0,0 +1,279 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	neturl "net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andreyst/tracker-messenger-bridge/config"
+	"github.com/andreyst/tracker-messenger-bridge/messenger"
+)
+
+// Name - messenger name
+const Name = "slack"
+
+const apiURL = "https://slack.com/api/"
+
+// Client - Slack implementation of messenger.Messenger, posts with Web API and receives updates from Events API
+// Slack IDs are mapped to numbers: channels with configured numbers, messages with timestamps in microseconds
+// and users with their IDs read as base 36 numbers
+type Client struct {
+	token    string
+	userName string
+	http     *http.Client
+
+	// Channel numbers by Slack channel ID and back
+	chats    map[string]int64
+	channels map[int64]string
+
+	mutex     sync.Mutex
+	userNames map[string]string
+}
+
+// Error - Slack refused request
+type Error struct {
+	Method string
+	Code   string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("slack %s: %s", e.Method, e.Code)
+}
+
+// New - creates client for bot token and checks it
+func New(cfg config.SlackConfig) (*Client, error) {
+	c := &Client{
+		token:     cfg.Token,
+		http:      &http.Client{Timeout: time.Minute},
+		chats:     make(map[string]int64),
+		channels:  make(map[int64]string),
+		userNames: make(map[string]string),
+	}
+	for channel, chatID := range cfg.Channels {
+		c.chats[channel] = chatID
+		c.channels[chatID] = channel
+	}
+
+	var auth struct {
+		User string `json:"user"`
+	}
+	err := c.call(context.Background(), "auth.test", neturl.Values{}, &auth)
+	if err != nil {
+		return nil, fmt.Errorf("unable to check slack token: %w", err)
+	}
+	c.userName = auth.User
+
+	return c, nil
+}
+
+// Name - returns messenger name
+func (c *Client) Name() string {
+	return Name
+}
+
+// UserName - returns user name of bot
+func (c *Client) UserName() string {
+	return c.userName
+}
+
+// Send - posts message, replies and messages to threads are posted to thread of replied or thread message
+func (c *Client) Send(ctx context.Context, msg messenger.Outgoing) (int64, error) {
+	params, err := c.textParams(msg)
+	if err != nil {
+		return 0, err
+	}
+	thread := msg.Thread
+	if thread == 0 {
+		thread = msg.ReplyTo
+	}
+	if thread != 0 {
+		params.Add("thread_ts", formatTS(thread))
+	}
+	params.Add("unfurl_links", "false")
+	params.Add("unfurl_media", "false")
+
+	var sent struct {
+		TS string `json:"ts"`
+	}
+	err = c.call(ctx, "chat.postMessage", params, &sent)
+	if err != nil {
+		return 0, err
+	}
+
+	return parseTS(sent.TS)
+}
+
+// Edit - replaces text of message
+func (c *Client) Edit(ctx context.Context, messageID int64, msg messenger.Outgoing) error {
+	params, err := c.textParams(msg)
+	if err != nil {
+		return err
+	}
+	params.Add("ts", formatTS(messageID))
+
+	return c.call(ctx, "chat.update", params, nil)
+}
+
+// Delete - deletes message, bot can delete only its own messages
+func (c *Client) Delete(ctx context.Context, chatID int64, messageID int64) error {
+	channel, ok := c.channels[chatID]
+	if !ok {
+		return fmt.Errorf("chat %d is not a configured slack channel", chatID)
+	}
+
+	return c.call(ctx, "chat.delete", neturl.Values{
+		"channel": {channel},
+		"ts":      {formatTS(messageID)},
+	}, nil)
+}
+
+// Download - downloads file by its private download URL, bot needs files:read scope
+func (c *Client) Download(ctx context.Context, fileID string) (io.ReadCloser, string, error) {
+	u, err := neturl.Parse(fileID)
+	if err != nil || u.Scheme != "https" || !strings.HasSuffix(u.Hostname(), ".slack.com") {
+		// Bot token must not be sent anywhere else
+		return nil, "", fmt.Errorf("unexpected slack file URL %q", fileID)
+	}
+
+	req, err := http.NewRequest("GET", fileID, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to download file: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, "", fmt.Errorf("unable to download file: unexpected status %s", resp.Status)
+	}
+
+	return resp.Body, path.Base(u.Path), nil
+}
+
+// Receive - Slack pushes events to webhook only
+func (c *Client) Receive(ctx context.Context, offset int64, save func(id string, payload []byte, next int64) error) error {
+	return messenger.ErrPushOnly
+}
+
+func (c *Client) textParams(msg messenger.Outgoing) (neturl.Values, error) {
+	channel, ok := c.channels[msg.ChatID]
+	if !ok {
+		return nil, fmt.Errorf("chat %d is not a configured slack channel", msg.ChatID)
+	}
+
+	text := escape(msg.Text)
+	if msg.Format == messenger.FormatMarkdown {
+		text = FromMarkdownV2(msg.Text)
+	}
+
+	return neturl.Values{
+		"channel": {channel},
+		"text":    {text},
+		"mrkdwn":  {strconv.FormatBool(msg.Format == messenger.FormatMarkdown)},
+	}, nil
+}
+
+// lookupUserName - returns user name of Slack user, names are cached until restart
+func (c *Client) lookupUserName(ctx context.Context, userID string) (string, error) {
+	c.mutex.Lock()
+	name, ok := c.userNames[userID]
+	c.mutex.Unlock()
+	if ok {
+		return name, nil
+	}
+
+	var info struct {
+		User struct {
+			Name string `json:"name"`
+		} `json:"user"`
+	}
+	err := c.call(ctx, "users.info", neturl.Values{"user": {userID}}, &info)
+	if err != nil {
+		return "", err
+	}
+
+	c.mutex.Lock()
+	c.userNames[userID] = info.User.Name
+	c.mutex.Unlock()
+
+	return info.User.Name, nil
+}
+
+// call - calls Web API method and parses response to result, which may be nil
+func (c *Client) call(ctx context.Context, method string, params neturl.Values, result interface{}) error {
+	req, err := http.NewRequest("POST", apiURL+method, strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return &messenger.RateLimitError{
+			Err:        Error{Method: method, Code: "ratelimited"},
+			RetryAfter: time.Duration(retryAfter) * time.Second,
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Error{Method: method, Code: resp.Status}
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var status struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	err = json.Unmarshal(body, &status)
+	if err != nil {
+		return fmt.Errorf("unable to parse slack %s response: %w", method, err)
+	}
+	if !status.OK {
+		return Error{Method: method, Code: status.Error}
+	}
+	if result == nil {
+		return nil
+	}
+
+	return json.Unmarshal(body, result)
+}
+
+// parseTS - converts message timestamp, e.g. "1700000000.000100", to number of microseconds
+func parseTS(ts string) (int64, error) {
+	parts := strings.SplitN(ts, ".", 2)
+	seconds, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || len(parts) != 2 || len(parts[1]) != 6 {
+		return 0, fmt.Errorf("unexpected slack timestamp %q", ts)
+	}
+	micros, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected slack timestamp %q", ts)
+	}
+
+	return seconds*1000000 + micros, nil
+}
+
+func formatTS(id int64) string {
+	return fmt.Sprintf("%d.%06d", id/1000000, id%1000000)
+}
@@ -0,0 +1,274 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	neturl "net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andreyst/tracker-messenger-bridge/messenger"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Name - messenger name, also used as queue item source of polled updates
+const Name = "telegram"
+
+// Client - Telegram implementation of messenger.Messenger and messenger.Topics
+// Client library does not know about topics and some newer parameters, so such requests are made directly
+type Client struct {
+	api      *tgbotapi.BotAPI
+	userName string
+}
+
+// New - creates client for bot with token
+func New(token string) (*Client, error) {
+	api, err := tgbotapi.NewBotAPI(token)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create telegram client: %w", err)
+	}
+
+	// NewBotAPI already called getMe
+	return &Client{api: api, userName: api.Self.UserName}, nil
+}
+
+// Name - returns messenger name
+func (c *Client) Name() string {
+	return Name
+}
+
+// UserName - returns user name of bot
+func (c *Client) UserName() string {
+	return c.userName
+}
+
+// Send - sends message
+func (c *Client) Send(ctx context.Context, msg messenger.Outgoing) (int64, error) {
+	params := textParams(msg)
+	params.Add("chat_id", strconv.FormatInt(msg.ChatID, 10))
+	if msg.Thread != 0 {
+		params.Add("message_thread_id", strconv.FormatInt(msg.Thread, 10))
+	}
+	if msg.ReplyTo != 0 {
+		params.Add("reply_to_message_id", strconv.FormatInt(msg.ReplyTo, 10))
+	}
+
+	resp, err := c.api.MakeRequest("sendMessage", params)
+	if err != nil {
+		return 0, wrapError(err)
+	}
+
+	var sent tgbotapi.Message
+	err = json.Unmarshal(resp.Result, &sent)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse sent message: %w", err)
+	}
+
+	return int64(sent.MessageID), nil
+}
+
+// Edit - replaces text of message
+func (c *Client) Edit(ctx context.Context, messageID int64, msg messenger.Outgoing) error {
+	params := textParams(msg)
+	params.Add("chat_id", strconv.FormatInt(msg.ChatID, 10))
+	params.Add("message_id", strconv.FormatInt(messageID, 10))
+
+	_, err := c.api.MakeRequest("editMessageText", params)
+	if isError(err, "message is not modified") {
+		return nil
+	}
+
+	return wrapError(err)
+}
+
+// Delete - deletes message, deleting messages of users requires admin rights
+func (c *Client) Delete(ctx context.Context, chatID int64, messageID int64) error {
+	_, err := c.api.DeleteMessage(tgbotapi.NewDeleteMessage(chatID, int(messageID)))
+
+	return wrapError(err)
+}
+
+// Download - downloads file, bots can not download files over 20 MB
+func (c *Client) Download(ctx context.Context, fileID string) (io.ReadCloser, string, error) {
+	file, err := c.api.GetFile(tgbotapi.FileConfig{FileID: fileID})
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to get file %s: %w", fileID, wrapError(err))
+	}
+
+	// File link contains bot token, it must not leak to errors
+	req, err := http.NewRequest("GET", file.Link(c.api.Token), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if urlErr, ok := err.(*neturl.Error); ok {
+		urlErr.URL = "Telegram file " + fileID
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to download file %s: %w", fileID, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, "", fmt.Errorf("unable to download file %s: unexpected status %s", fileID, resp.Status)
+	}
+
+	return resp.Body, path.Base(file.FilePath), nil
+}
+
+// Receive - long polls for updates, webhook is removed first, as updates can not be polled while it is set
+// Updates are requested one batch at a time and offset is moved past an update only after it was saved,
+// as Telegram forgets updates before offset of the next request
+func (c *Client) Receive(ctx context.Context, offset int64, save func(id string, payload []byte, next int64) error) error {
+	_, err := c.api.RemoveWebhook()
+	if err != nil {
+		return fmt.Errorf("unable to remove webhook: %w", err)
+	}
+
+	for {
+		updates, err := c.getUpdates(ctx, offset)
+		if ctx.Err() != nil {
+			// Updates received meanwhile are not saved, they are requested again after restart
+			return nil
+		}
+		if err != nil {
+			err = wrapError(err)
+			log.Printf("Unable to get updates, retrying: %v\n", err)
+
+			delay := 3 * time.Second
+			var rateLimitErr *messenger.RateLimitError
+			if errors.As(err, &rateLimitErr) {
+				delay = rateLimitErr.RetryAfter
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(delay):
+			}
+			continue
+		}
+
+		for _, update := range updates {
+			buf, err := json.Marshal(update)
+			if err != nil {
+				return fmt.Errorf("unable to marshal update %d: %w", update.UpdateID, err)
+			}
+
+			next := int64(update.UpdateID) + 1
+			err = save(strconv.Itoa(update.UpdateID), buf, next)
+			if err != nil {
+				return err
+			}
+			offset = next
+		}
+	}
+}
+
+// getUpdates - long polls for updates starting from offset, returns when ctx is done without waiting for response,
+// which is discarded then
+func (c *Client) getUpdates(ctx context.Context, offset int64) ([]tgbotapi.Update, error) {
+	type result struct {
+		updates []tgbotapi.Update
+		err     error
+	}
+
+	u := tgbotapi.NewUpdate(int(offset))
+	u.Timeout = 60
+
+	// Buffered, so that request goroutine exits after ctx is done
+	done := make(chan result, 1)
+	go func() {
+		updates, err := c.api.GetUpdates(u)
+		done <- result{updates, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-done:
+		return r.updates, r.err
+	}
+}
+
+// SetWebhook - points Telegram webhook to url, secret is sent back with every update
+func (c *Client) SetWebhook(url string, secret string) error {
+	// tgbotapi.WebhookConfig does not support secret_token, so request is made directly
+	_, err := c.api.MakeRequest("setWebhook", neturl.Values{
+		"url":          {url},
+		"secret_token": {secret},
+	})
+
+	return wrapError(err)
+}
+
+// CreateTopic - creates forum topic, bot has to be administrator allowed to manage topics
+func (c *Client) CreateTopic(ctx context.Context, chatID int64, name string) (int64, error) {
+	params := neturl.Values{}
+	params.Add("chat_id", strconv.FormatInt(chatID, 10))
+	params.Add("name", name)
+	resp, err := c.api.MakeRequest("createForumTopic", params)
+	if err != nil {
+		return 0, wrapError(err)
+	}
+
+	var topic struct {
+		ThreadID int64 `json:"message_thread_id"`
+	}
+	err = json.Unmarshal(resp.Result, &topic)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse created topic: %w", err)
+	}
+
+	return topic.ThreadID, nil
+}
+
+// SetTopicClosed - closes or reopens forum topic
+func (c *Client) SetTopicClosed(ctx context.Context, chatID int64, threadID int64, closed bool) error {
+	method := "reopenForumTopic"
+	if closed {
+		method = "closeForumTopic"
+	}
+
+	params := neturl.Values{}
+	params.Add("chat_id", strconv.FormatInt(chatID, 10))
+	params.Add("message_thread_id", strconv.FormatInt(threadID, 10))
+	_, err := c.api.MakeRequest(method, params)
+	if isError(err, "TOPIC_NOT_MODIFIED") {
+		return nil
+	}
+
+	return wrapError(err)
+}
+
+func textParams(msg messenger.Outgoing) neturl.Values {
+	params := neturl.Values{}
+	params.Add("text", msg.Text)
+	params.Add("disable_web_page_preview", "true")
+	if msg.Format == messenger.FormatMarkdown {
+		params.Add("parse_mode", "MarkdownV2")
+	}
+
+	return params
+}
+
+// isError - checks whether Telegram refused request with description containing text
+func isError(err error, text string) bool {
+	var telegramErr tgbotapi.Error
+	return errors.As(err, &telegramErr) && strings.Contains(telegramErr.Message, text)
+}
+
+// wrapError - marks flood control errors as rate limit ones, Telegram sets retry_after only for them
+func wrapError(err error) error {
+	var telegramErr tgbotapi.Error
+	if errors.As(err, &telegramErr) && telegramErr.RetryAfter > 0 {
+		return &messenger.RateLimitError{Err: err, RetryAfter: time.Duration(telegramErr.RetryAfter) * time.Second}
+	}
+
+	return err
+}
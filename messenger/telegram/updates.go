@@ -0,0 +1,95 @@
+package telegram
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/andreyst/tracker-messenger-bridge/markdown"
+	"github.com/andreyst/tracker-messenger-bridge/messenger"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Decode - converts new and edited messages, other updates are skipped
+func (c *Client) Decode(payload []byte) (messenger.Update, bool, error) {
+	var update tgbotapi.Update
+	err := json.Unmarshal(payload, &update)
+	if err != nil {
+		return messenger.Update{}, false, err
+	}
+
+	u := messenger.Update{ID: strconv.Itoa(update.UpdateID)}
+	switch {
+	case update.Message != nil:
+		u.Message = convertMessage(update.Message)
+	case update.EditedMessage != nil:
+		u.EditedMessage = convertMessage(update.EditedMessage)
+	default:
+		return messenger.Update{}, false, nil
+	}
+
+	return u, true, nil
+}
+
+func convertMessage(message *tgbotapi.Message) *messenger.Message {
+	var entities []tgbotapi.MessageEntity
+	if message.Entities != nil {
+		entities = *message.Entities
+	}
+
+	m := &messenger.Message{
+		ID:       int64(message.MessageID),
+		ChatID:   message.Chat.ID,
+		Private:  message.Chat.IsPrivate(),
+		Text:     message.Text,
+		Markdown: markdown.FromTelegram(message.Text, entities),
+	}
+	if message.Caption != "" {
+		// Telegram client library does not expose caption entities, caption is shown as plain text
+		m.Markdown = markdown.EscapeGithub(message.Caption)
+	}
+	if message.From != nil {
+		m.From = &messenger.User{ID: message.From.ID, UserName: message.From.UserName}
+	}
+	if a, ok := messageAttachment(message); ok {
+		m.Attachments = []messenger.Attachment{a}
+	}
+	if message.ReplyToMessage != nil {
+		m.ReplyTo = convertMessage(message.ReplyToMessage)
+	}
+
+	return m
+}
+
+func messageAttachment(message *tgbotapi.Message) (messenger.Attachment, bool) {
+	switch {
+	case message.Photo != nil && len(*message.Photo) > 0:
+		// Sizes are sorted from smallest to largest
+		photo := (*message.Photo)[len(*message.Photo)-1]
+		return messenger.Attachment{FileID: photo.FileID, Name: "photo", Label: "photo", Size: photo.FileSize}, true
+	case message.Sticker != nil:
+		return messenger.Attachment{FileID: message.Sticker.FileID, Name: "sticker", Label: strings.TrimSpace("sticker " + message.Sticker.Emoji), Size: message.Sticker.FileSize}, true
+	case message.Animation != nil:
+		return messenger.Attachment{FileID: message.Animation.FileID, Name: message.Animation.FileName, Label: "animation", Size: message.Animation.FileSize}, true
+	case message.Document != nil:
+		label := message.Document.FileName
+		if label == "" {
+			label = "document"
+		}
+		return messenger.Attachment{FileID: message.Document.FileID, Name: message.Document.FileName, Label: label, Size: message.Document.FileSize}, true
+	case message.Video != nil:
+		return messenger.Attachment{FileID: message.Video.FileID, Name: "video", Label: "video", Size: message.Video.FileSize}, true
+	case message.VideoNote != nil:
+		return messenger.Attachment{FileID: message.VideoNote.FileID, Name: "video", Label: "video message", Size: message.VideoNote.FileSize}, true
+	case message.Voice != nil:
+		return messenger.Attachment{FileID: message.Voice.FileID, Name: "voice", Label: "voice message", Size: message.Voice.FileSize}, true
+	case message.Audio != nil:
+		label := message.Audio.Title
+		if label == "" {
+			label = "audio"
+		}
+		return messenger.Attachment{FileID: message.Audio.FileID, Name: "audio", Label: label, Size: message.Audio.FileSize}, true
+	}
+
+	return messenger.Attachment{}, false
+}
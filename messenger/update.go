@@ -0,0 +1,50 @@
+package messenger
+
+// KindUpdate - kind of event envelopes with Update payload
+const KindUpdate = "messenger.update"
+
+// Update - incoming update, only one of messages is set
+type Update struct {
+	// ID unique within messenger, used to skip repeated deliveries
+	ID string
+	// New message
+	Message *Message
+	// Message which was edited, with new text
+	EditedMessage *Message
+}
+
+// Message - incoming message
+type Message struct {
+	ID     int64
+	ChatID int64
+	// Message was sent in private chat with bot
+	Private bool
+	// Author, nil for messages without one, e.g. channel posts
+	From *User
+	// Plain text, used to parse commands
+	Text string
+	// Text converted to Github Markdown, without attachments
+	Markdown string
+	// Attached files
+	Attachments []Attachment
+	// Message this one replies to, nil if it is not a reply
+	ReplyTo *Message
+}
+
+// User - messenger user
+type User struct {
+	ID       int
+	UserName string
+}
+
+// Attachment - file attached to message
+type Attachment struct {
+	// ID to download file with
+	FileID string
+	// File name, extension is taken from downloaded file when it has none
+	Name string
+	// Human readable description, e.g. "photo" or file name
+	Label string
+	// Size in bytes, 0 when unknown
+	Size int
+}
@@ -0,0 +1,152 @@
+package permissions
+
+import (
+	"errors"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andreyst/tracker-messenger-bridge/config"
+)
+
+// Role - role of Telegram user, higher roles can do everything lower roles can
+type Role int
+
+// Roles ordered from lowest to highest
+const (
+	Viewer Role = iota
+	Staff
+	Maintainer
+)
+
+// String - returns role name as used in config
+func (r Role) String() string {
+	switch r {
+	case Staff:
+		return config.RoleStaff
+	case Maintainer:
+		return config.RoleMaintainer
+	default:
+		return config.RoleViewer
+	}
+}
+
+func parseRole(name string) Role {
+	switch name {
+	case config.RoleStaff:
+		return Staff
+	case config.RoleMaintainer:
+		return Maintainer
+	default:
+		return Viewer
+	}
+}
+
+// Policy - finds roles of Telegram users
+// Grants can be replaced at runtime with SetGrants or Replace
+type Policy struct {
+	mutex  sync.RWMutex
+	grants []config.Grant
+}
+
+// NewPolicy - creates policy, users without matching grants are viewers
+func NewPolicy(grants []config.Grant) (*Policy, error) {
+	p := &Policy{}
+
+	err := p.SetGrants(grants)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// SetGrants - validates and replaces grants
+func (p *Policy) SetGrants(grants []config.Grant) error {
+	if errs := config.ValidatePermissions(grants); len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.grants = append([]config.Grant(nil), grants...)
+
+	return nil
+}
+
+// Replace - replaces grants with those of other policy, e.g. built from reloaded config
+func (p *Policy) Replace(other *Policy) {
+	other.mutex.RLock()
+	grants := other.grants
+	other.mutex.RUnlock()
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.grants = grants
+}
+
+// RoleOf - returns highest role granted to Telegram user in chat for repo ("owner/repo"),
+// empty repo matches only grants not limited to repos
+func (p *Policy) RoleOf(userID int, chatID int64, repo string) Role {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	role := Viewer
+	for _, grant := range p.grants {
+		if !matchGrant(grant, userID, chatID, repo) {
+			continue
+		}
+
+		if r := parseRole(grant.Role); r > role {
+			role = r
+		}
+	}
+
+	return role
+}
+
+// matchGrant - checks that grant applies to user in chat for repo, users are matched by IDs only,
+// Slack member IDs are compared as base 36 numbers, the way Slack adapter converts them to user IDs
+func matchGrant(grant config.Grant, userID int, chatID int64, repo string) bool {
+	userMatched := false
+	for _, user := range grant.Users {
+		id, err := strconv.ParseInt(user, 10, 64)
+		if err != nil {
+			id, err = strconv.ParseInt(user, 36, 64)
+		}
+		if err == nil && id == int64(userID) {
+			userMatched = true
+			break
+		}
+	}
+	if !userMatched {
+		return false
+	}
+
+	if len(grant.Chats) > 0 {
+		chatMatched := false
+		for _, id := range grant.Chats {
+			if id == chatID {
+				chatMatched = true
+				break
+			}
+		}
+		if !chatMatched {
+			return false
+		}
+	}
+
+	if len(grant.Repos) > 0 {
+		for _, pattern := range grant.Repos {
+			if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(repo)); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
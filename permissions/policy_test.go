@@ -0,0 +1,85 @@
+package permissions
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/andreyst/tracker-messenger-bridge/config"
+)
+
+func slackUserID(t *testing.T, memberID string) int {
+	t.Helper()
+
+	id, err := strconv.ParseInt(memberID, 36, 64)
+	if err != nil {
+		t.Fatalf("unable to convert %s: %v", memberID, err)
+	}
+
+	return int(id)
+}
+
+func TestRoleOf(t *testing.T) {
+	policy, err := NewPolicy([]config.Grant{
+		{Users: []string{"1001"}, Role: config.RoleStaff},
+		{Users: []string{"1001"}, Role: config.RoleMaintainer, Repos: []string{"andreyst/*"}},
+		{Users: []string{"1002"}, Role: config.RoleMaintainer, Chats: []int64{-100}},
+		{Users: []string{"U0123ABC"}, Role: config.RoleStaff},
+		{Users: []string{"1003", "1004"}, Role: config.RoleStaff, Chats: []int64{-200}, Repos: []string{"andreyst/bridge"}},
+	})
+	if err != nil {
+		t.Fatalf("NewPolicy() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		userID int
+		chatID int64
+		repo   string
+		want   Role
+	}{
+		{"no grants", 2000, -100, "andreyst/bridge", Viewer},
+		{"grant without limits", 1001, -300, "other/repo", Staff},
+		{"highest of matching grants", 1001, -300, "andreyst/bridge", Maintainer},
+		{"repo pattern is case insensitive", 1001, -300, "AndreySt/Bridge", Maintainer},
+		{"empty repo matches only grants without repos", 1001, -300, "", Staff},
+		{"grant limited to chat", 1002, -100, "andreyst/bridge", Maintainer},
+		{"grant in other chat", 1002, -300, "andreyst/bridge", Viewer},
+		{"second user of grant", 1004, -200, "andreyst/bridge", Staff},
+		{"grant limited to chat and repo, other repo", 1004, -200, "andreyst/other", Viewer},
+		{"grant limited to chat and repo, other chat", 1004, -100, "andreyst/bridge", Viewer},
+		{"slack member ID matched as base 36 number", slackUserID(t, "U0123ABC"), -300, "", Staff},
+		{"numeric ID is not matched as base 36 number", slackUserID(t, "1001"), -300, "", Viewer},
+		{"other slack member", slackUserID(t, "U0123ABD"), -300, "", Viewer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.RoleOf(tt.userID, tt.chatID, tt.repo); got != tt.want {
+				t.Errorf("RoleOf(%d, %d, %q) = %v, want %v", tt.userID, tt.chatID, tt.repo, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetGrants(t *testing.T) {
+	policy, err := NewPolicy([]config.Grant{{Users: []string{"1001"}, Role: config.RoleMaintainer}})
+	if err != nil {
+		t.Fatalf("NewPolicy() error = %v", err)
+	}
+
+	err = policy.SetGrants([]config.Grant{{Users: []string{"@andreyst"}, Role: config.RoleStaff}})
+	if err == nil {
+		t.Fatalf("SetGrants() accepted username instead of user ID")
+	}
+	if got := policy.RoleOf(1001, -100, ""); got != Maintainer {
+		t.Errorf("RoleOf() after invalid SetGrants() = %v, want previous grants kept", got)
+	}
+
+	err = policy.SetGrants(nil)
+	if err != nil {
+		t.Fatalf("SetGrants() error = %v", err)
+	}
+	if got := policy.RoleOf(1001, -100, ""); got != Viewer {
+		t.Errorf("RoleOf() after SetGrants(nil) = %v, want %v", got, Viewer)
+	}
+}
@@ -0,0 +1,212 @@
+package pollers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	bot "github.com/andreyst/tracker-messenger-bridge/bot"
+	"github.com/andreyst/tracker-messenger-bridge/markdown"
+	"github.com/andreyst/tracker-messenger-bridge/messenger"
+	"github.com/andreyst/tracker-messenger-bridge/routing"
+	"github.com/andreyst/tracker-messenger-bridge/storage"
+)
+
+// DigestSource - name to register DigestPoller with
+const DigestSource = "digest"
+
+// sqliteTimeFormat - format of datetime("now") in storage, UTC
+const sqliteTimeFormat = "2006-01-02 15:04:05"
+
+// DigestPoller - posts digests of events collected for chats in digest mode when they are due
+// Events are kept in DB until digest is posted, so digest pending at restart is posted after it
+type DigestPoller struct{}
+
+// Start - starts poller
+func (DigestPoller) Start(ctx context.Context, b *bot.Bot) error {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		// Digests due while bot was stopped are posted right away
+		postDueDigests(ctx, b, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// postDueDigests - posts digests with events older than their last scheduled time,
+// failed digests are retried on the next tick
+func postDueDigests(ctx context.Context, b *bot.Bot, now time.Time) {
+	pending, err := b.PendingDigests()
+	if err != nil {
+		log.Printf("Unable to look up pending digests: %v\n", err)
+		return
+	}
+
+	for _, p := range pending {
+		due, err := routing.LastDigestTime(p.Schedule, now)
+		if err != nil {
+			log.Printf("Skipping digest for chat %d: %v\n", p.ChatID, err)
+			continue
+		}
+		before := due.UTC().Format(sqliteTimeFormat)
+		if p.Since >= before {
+			continue
+		}
+
+		err = postDigest(ctx, b, p.ChatID, p.Schedule, before)
+		if err != nil {
+			log.Printf("Unable to post digest to chat %d: %v\n", p.ChatID, err)
+		}
+	}
+}
+
+func postDigest(ctx context.Context, b *bot.Bot, chatID int64, schedule string, before string) error {
+	events, err := b.DigestEvents(chatID, schedule, before)
+	if err != nil {
+		return fmt.Errorf("unable to load digest events: %w", err)
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	for _, part := range splitDigest(renderDigest(events)) {
+		_, err = b.Messenger.Send(ctx, messenger.Outgoing{
+			ChatID: chatID,
+			Text:   part.text,
+			Format: messenger.FormatMarkdown,
+		})
+		if err != nil {
+			// Events of parts not posted yet are kept, so that only they are posted again
+			return err
+		}
+
+		// Events are deleted as soon as their part is posted, so that they are not posted twice
+		err = b.DeleteDigestEvents(part.events)
+		if err != nil {
+			return fmt.Errorf("unable to delete posted digest events: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// digestLine - line of digest and events it covers
+type digestLine struct {
+	text   string
+	events []int64
+}
+
+// digestItem - events of one issue or pull request
+type digestItem struct {
+	event  storage.DigestEvent
+	counts map[string]int
+	rowIDs []int64
+}
+
+// renderDigest - renders events grouped by repo and issue as MarkdownV2 lines, repos and issues are kept in order of first event
+func renderDigest(events []storage.DigestEvent) []digestLine {
+	var repos []string
+	items := make(map[string][]*digestItem)
+	byIssue := make(map[string]*digestItem)
+	totals := make(map[string]int)
+	for _, e := range events {
+		key := fmt.Sprintf("%s#%d", e.Repo, e.Number)
+		item, ok := byIssue[key]
+		if !ok {
+			item = &digestItem{event: e, counts: make(map[string]int)}
+			byIssue[key] = item
+			if _, ok := items[e.Repo]; !ok {
+				repos = append(repos, e.Repo)
+			}
+			items[e.Repo] = append(items[e.Repo], item)
+		}
+		// The latest title is shown
+		item.event.Title = e.Title
+		item.counts[e.Action]++
+		item.rowIDs = append(item.rowIDs, e.RowID)
+		totals[e.Action]++
+	}
+
+	lines := []digestLine{{text: "📋 *Digest:* " + markdown.Escape(summary(totals, false))}}
+	for _, repo := range repos {
+		lines = append(lines, digestLine{}, digestLine{text: "*" + markdown.Escape(repo) + "*"})
+		for _, item := range items[repo] {
+			kind := ""
+			if item.event.PullRequest {
+				kind = "PR "
+			}
+			lines = append(lines, digestLine{
+				text: fmt.Sprintf(
+					"• %s\\#%d %s: %s",
+					kind,
+					item.event.Number,
+					markdown.Link(item.event.Title, item.event.URL),
+					markdown.Escape(summary(item.counts, true)),
+				),
+				events: item.rowIDs,
+			})
+		}
+	}
+
+	return lines
+}
+
+// summary - describes counts of actions, e.g. "2 new, 1 closed, 3 comments",
+// counts of status changes are omitted for single issue
+func summary(counts map[string]int, single bool) string {
+	var parts []string
+	add := func(action string, one string, many string) {
+		n := counts[action]
+		switch {
+		case n == 0:
+		case single && one == many:
+			parts = append(parts, one)
+		case n == 1:
+			parts = append(parts, "1 "+one)
+		default:
+			parts = append(parts, fmt.Sprintf("%d %s", n, many))
+		}
+	}
+	add(storage.DigestActionOpened, "new", "new")
+	add(storage.DigestActionClosed, "closed", "closed")
+	add(storage.DigestActionMerged, "merged", "merged")
+	add(storage.DigestActionReopened, "reopened", "reopened")
+	add(storage.DigestActionCommented, "comment", "comments")
+	add(storage.DigestActionReviewed, "review", "reviews")
+
+	return strings.Join(parts, ", ")
+}
+
+// splitDigest - joins lines into as few messages fitting Telegram limit as possible,
+// each message covers events of its lines
+func splitDigest(lines []digestLine) []digestLine {
+	var parts []digestLine
+	var current digestLine
+	for _, line := range lines {
+		if current.text != "" && markdown.Length(current.text+"\n"+line.text) > markdown.MaxLength {
+			current.text = strings.TrimSpace(current.text)
+			parts = append(parts, current)
+			current = digestLine{}
+		}
+		if current.text == "" {
+			current.text = line.text
+		} else {
+			current.text += "\n" + line.text
+		}
+		current.events = append(current.events, line.events...)
+	}
+	if strings.TrimSpace(current.text) != "" {
+		current.text = strings.TrimSpace(current.text)
+		parts = append(parts, current)
+	}
+
+	return parts
+}
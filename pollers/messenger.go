@@ -0,0 +1,59 @@
+package pollers
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	bot "github.com/andreyst/tracker-messenger-bridge/bot"
+	"github.com/andreyst/tracker-messenger-bridge/messenger"
+	"github.com/andreyst/tracker-messenger-bridge/storage"
+)
+
+// MessengerPoller - polls bot messenger for updates, should be registered with messenger name
+// Updates are saved to bot queue before processing, polling continues
+// from the last queued update after restart
+type MessengerPoller struct{}
+
+func init() {
+	bot.RegisterEventKind(messenger.KindUpdate, messenger.Update{})
+}
+
+// Start - starts poller
+func (MessengerPoller) Start(ctx context.Context, b *bot.Bot) error {
+	source := b.Messenger.Name()
+	offset, err := b.SourceOffset(source)
+	if err != nil {
+		return fmt.Errorf("unable to load offset: %w", err)
+	}
+
+	return b.Messenger.Receive(ctx, offset, func(id string, payload []byte, next int64) error {
+		item := storage.QueueItem{
+			Source:   source,
+			SourceID: id,
+			Payload:  string(payload),
+		}
+		queued, err := b.EnqueueWithOffset(item, next)
+		if err != nil {
+			return fmt.Errorf("unable to queue update %s: %w", id, err)
+		}
+		if !queued {
+			log.Printf("Skipping duplicate %s update %s\n", source, id)
+		}
+
+		return nil
+	})
+}
+
+// HandleQueueItem - passes queued update to event handlers
+func (MessengerPoller) HandleQueueItem(ctx context.Context, b *bot.Bot, item storage.QueueItem) error {
+	update, ok, err := b.Messenger.Decode([]byte(item.Payload))
+	if err != nil {
+		return bot.Unprocessed("unparseable %s update %s: %v", item.Source, item.SourceID, err)
+	}
+	if !ok {
+		return nil
+	}
+
+	return b.Emit(ctx, item.Source, update.ID, update)
+}
@@ -0,0 +1,178 @@
+package routing
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/andreyst/tracker-messenger-bridge/config"
+)
+
+// Event types used in routes
+const (
+	EventIssues                   = "issues"
+	EventIssueComment             = "issue_comment"
+	EventPullRequest              = "pull_request"
+	EventPullRequestReview        = "pull_request_review"
+	EventPullRequestReviewComment = "pull_request_review_comment"
+)
+
+// Router - finds chats to send Github events to
+// Routes can be replaced at runtime with SetRoutes or Replace
+type Router struct {
+	mutex         sync.RWMutex
+	routes        []config.Route
+	defaultChatID int64
+}
+
+// NewRouter - creates router, events not matching any route go to defaultChatID
+func NewRouter(routes []config.Route, defaultChatID int64) (*Router, error) {
+	r := &Router{defaultChatID: defaultChatID}
+
+	err := r.SetRoutes(routes)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// SetRoutes - validates and replaces routes
+func (r *Router) SetRoutes(routes []config.Route) error {
+	if errs := config.ValidateRoutes(routes); len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.routes = append([]config.Route(nil), routes...)
+
+	return nil
+}
+
+// Replace - replaces routes and default chat with those of other router, e.g. built from reloaded config
+func (r *Router) Replace(other *Router) {
+	other.mutex.RLock()
+	routes, defaultChatID := other.routes, other.defaultChatID
+	other.mutex.RUnlock()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.routes = routes
+	r.defaultChatID = defaultChatID
+}
+
+// Routes - returns current routes
+func (r *Router) Routes() []config.Route {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return append([]config.Route(nil), r.routes...)
+}
+
+// DigestTarget - chat collecting events for digest posted on schedule
+type DigestTarget struct {
+	ChatID   int64
+	Schedule string
+}
+
+// Targets - returns chats to post event of given type in repo ("owner/repo") with labels to right away
+func (r *Router) Targets(repo string, event string, labels []string) []int64 {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var chats []int64
+	matched := false
+	seen := make(map[int64]bool)
+	for _, route := range r.routes {
+		if !matchRoute(route, repo, event, labels) {
+			continue
+		}
+		// Chats of digest-only routes get event later, it does not go to default chat either
+		matched = true
+		if route.Mode == config.RouteModeDigest {
+			continue
+		}
+
+		for _, chatID := range route.Chats {
+			if !seen[chatID] {
+				seen[chatID] = true
+				chats = append(chats, chatID)
+			}
+		}
+	}
+
+	if !matched && r.defaultChatID != 0 {
+		chats = append(chats, r.defaultChatID)
+	}
+
+	return chats
+}
+
+// DigestTargets - returns chats which collect event of given type in repo ("owner/repo") with labels for digests
+func (r *Router) DigestTargets(repo string, event string, labels []string) []DigestTarget {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var targets []DigestTarget
+	seen := make(map[DigestTarget]bool)
+	for _, route := range r.routes {
+		if route.Mode != config.RouteModeDigest && route.Mode != config.RouteModeBoth {
+			continue
+		}
+		if !matchRoute(route, repo, event, labels) {
+			continue
+		}
+
+		for _, chatID := range route.Chats {
+			target := DigestTarget{ChatID: chatID, Schedule: route.Schedule}
+			if !seen[target] {
+				seen[target] = true
+				targets = append(targets, target)
+			}
+		}
+	}
+
+	return targets
+}
+
+func matchRoute(route config.Route, repo string, event string, labels []string) bool {
+	repoMatched := false
+	for _, pattern := range route.Repos {
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(repo)); ok {
+			repoMatched = true
+			break
+		}
+	}
+	if !repoMatched {
+		return false
+	}
+
+	if len(route.Events) > 0 && !contains(route.Events, event) {
+		return false
+	}
+
+	if len(route.Labels) > 0 {
+		for _, label := range labels {
+			if contains(route.Labels, label) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
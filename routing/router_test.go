@@ -0,0 +1,105 @@
+package routing
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/andreyst/tracker-messenger-bridge/config"
+)
+
+func newTestRouter(t *testing.T, defaultChatID int64) *Router {
+	t.Helper()
+
+	router, err := NewRouter([]config.Route{
+		{Repos: []string{"andreyst/*"}, Chats: []int64{-100}},
+		{Repos: []string{"andreyst/bridge"}, Chats: []int64{-200, -100}, Events: []string{EventPullRequest}},
+		{Repos: []string{"andreyst/bridge"}, Chats: []int64{-300}, Labels: []string{"bug"}},
+		{Repos: []string{"other/*"}, Chats: []int64{-400}, Mode: config.RouteModeDigest, Schedule: "daily 09:00"},
+		{Repos: []string{"other/api"}, Chats: []int64{-500}, Mode: config.RouteModeBoth, Schedule: "hourly"},
+		{Repos: []string{"*/docs"}, Chats: []int64{-600}, Events: []string{EventIssues}},
+	}, defaultChatID)
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	return router
+}
+
+func TestTargets(t *testing.T) {
+	router := newTestRouter(t, -1)
+
+	tests := []struct {
+		name   string
+		repo   string
+		event  string
+		labels []string
+		want   []int64
+	}{
+		{"wildcard repo", "andreyst/other", EventIssues, nil, []int64{-100}},
+		{"repo is case insensitive", "AndreySt/Other", EventIssues, nil, []int64{-100}},
+		{"multiple matching routes without duplicates", "andreyst/bridge", EventPullRequest, nil, []int64{-100, -200}},
+		{"route limited to other event", "andreyst/bridge", EventIssues, nil, []int64{-100}},
+		{"route limited to label", "andreyst/bridge", EventIssues, []string{"Bug"}, []int64{-100, -300}},
+		{"wildcard owner", "someone/docs", EventIssues, nil, []int64{-600}},
+		{"no matching route goes to default chat", "someone/docs", EventIssueComment, nil, []int64{-1}},
+		{"digest route does not go to default chat", "other/web", EventIssues, nil, nil},
+		{"both mode route", "other/api", EventIssues, nil, []int64{-500}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := router.Targets(tt.repo, tt.event, tt.labels); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Targets(%q, %q, %v) = %v, want %v", tt.repo, tt.event, tt.labels, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTargetsWithoutDefaultChat(t *testing.T) {
+	router := newTestRouter(t, 0)
+
+	if got := router.Targets("someone/repo", EventIssues, nil); got != nil {
+		t.Errorf("Targets() = %v, want no chats", got)
+	}
+}
+
+func TestDigestTargets(t *testing.T) {
+	router := newTestRouter(t, -1)
+
+	tests := []struct {
+		name  string
+		repo  string
+		event string
+		want  []DigestTarget
+	}{
+		{"digest route", "other/web", EventIssues, []DigestTarget{{ChatID: -400, Schedule: "daily 09:00"}}},
+		{"digest and both mode routes", "other/api", EventPullRequest, []DigestTarget{
+			{ChatID: -400, Schedule: "daily 09:00"},
+			{ChatID: -500, Schedule: "hourly"},
+		}},
+		{"realtime routes only", "andreyst/bridge", EventPullRequest, nil},
+		{"no matching route", "someone/repo", EventIssues, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := router.DigestTargets(tt.repo, tt.event, nil); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DigestTargets(%q, %q) = %v, want %v", tt.repo, tt.event, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplace(t *testing.T) {
+	router := newTestRouter(t, -1)
+
+	other, err := NewRouter(nil, -2)
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	router.Replace(other)
+
+	if got := router.Targets("andreyst/bridge", EventIssues, nil); !reflect.DeepEqual(got, []int64{-2}) {
+		t.Errorf("Targets() after Replace() = %v, want reloaded default chat", got)
+	}
+}
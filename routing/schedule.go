@@ -0,0 +1,32 @@
+package routing
+
+import (
+	"fmt"
+	"time"
+)
+
+// Digest schedules, see config.Route
+const (
+	scheduleHourly = "hourly"
+	scheduleDaily  = "daily"
+)
+
+// LastDigestTime - returns the latest time at or before now digest with schedule was due at, in location of now
+func LastDigestTime(schedule string, now time.Time) (time.Time, error) {
+	if schedule == scheduleHourly {
+		return time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 0, 0, now.Location()), nil
+	}
+
+	var hour, minute int
+	_, err := fmt.Sscanf(schedule, scheduleDaily+" %d:%d", &hour, &minute)
+	if err != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return time.Time{}, fmt.Errorf("invalid digest schedule %q", schedule)
+	}
+
+	due := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if due.After(now) {
+		due = due.AddDate(0, 0, -1)
+	}
+
+	return due, nil
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"database/sql"
+)
+
+// AuditEntry records authorization decision for action of Telegram user
+type AuditEntry struct {
+	RowID     int64
+	CreatedAt string
+	// Source and ID of event action was requested in, decision is recorded once per event and action
+	EventSource      string
+	EventID          string
+	TelegramUserID   int64
+	TelegramUserName string
+	ChatID           int64
+	Repo             string
+	Action           string
+	Role             string
+	RequiredRole     string
+	Allowed          bool
+}
+
+// SaveAuditEntry saves audit entry to db, entry for the same event and action is saved only once,
+// so that retried events are not audited again
+func SaveAuditEntry(db *sql.DB, entry AuditEntry) error {
+	_, err := db.Exec(`
+	INSERT OR IGNORE INTO audit_log(
+		created_at, event_source, event_id, telegram_user_id, telegram_username, chat_id, repo, action, role, required_role, allowed
+	) VALUES(
+		datetime("now"),
+		$1,
+		$2,
+		$3,
+		$4,
+		$5,
+		$6,
+		$7,
+		$8,
+		$9,
+		$10
+	)
+	`, entry.EventSource, entry.EventID, entry.TelegramUserID, entry.TelegramUserName, entry.ChatID, entry.Repo, entry.Action, entry.Role, entry.RequiredRole, entry.Allowed)
+
+	return err
+}
+
+// ListAuditEntries lists latest audit entries, newest first, only refusals if deniedOnly is set
+func ListAuditEntries(db *sql.DB, deniedOnly bool, limit int) ([]AuditEntry, error) {
+	rows, err := db.Query(`
+	SELECT rowid, created_at, telegram_user_id, telegram_username, chat_id, repo, action, role, required_role, allowed
+	FROM audit_log
+	WHERE allowed = 0 OR NOT $1
+	ORDER BY rowid DESC
+	LIMIT $2
+	`, deniedOnly, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		err = rows.Scan(&e.RowID, &e.CreatedAt, &e.TelegramUserID, &e.TelegramUserName, &e.ChatID, &e.Repo, &e.Action, &e.Role, &e.RequiredRole, &e.Allowed)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"database/sql"
+)
+
+// DeadLetter stores event which event handler failed to process
+type DeadLetter struct {
+	RowID      int64
+	CreatedAt  string
+	UpdatedAt  string
+	Handler    string
+	Kind       string
+	Payload    string
+	Error      string
+	Attempts   int
+	ReplayedAt string
+}
+
+// SaveDeadLetter saves dead letter to db
+func SaveDeadLetter(db *sql.DB, dl DeadLetter) (DeadLetter, error) {
+	res, err := db.Exec(`
+	INSERT INTO dead_letters(created_at, updated_at, handler, kind, payload, error, attempts) VALUES(
+		datetime("now"),
+		datetime("now"),
+		$1,
+		$2,
+		$3,
+		$4,
+		$5
+	)
+	`, dl.Handler, dl.Kind, dl.Payload, dl.Error, dl.Attempts)
+	if err != nil {
+		return dl, err
+	}
+
+	dl.RowID, err = res.LastInsertId()
+
+	return dl, err
+}
+
+// ListDeadLetters lists dead letters which were not replayed successfully yet
+func ListDeadLetters(db *sql.DB) ([]DeadLetter, error) {
+	rows, err := db.Query(`
+	SELECT rowid, created_at, updated_at, handler, kind, payload, error, attempts, replayed_at
+	FROM dead_letters
+	WHERE replayed_at = ''
+	ORDER BY rowid
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dls []DeadLetter
+	for rows.Next() {
+		var dl DeadLetter
+		err = rows.Scan(&dl.RowID, &dl.CreatedAt, &dl.UpdatedAt, &dl.Handler, &dl.Kind, &dl.Payload, &dl.Error, &dl.Attempts, &dl.ReplayedAt)
+		if err != nil {
+			return nil, err
+		}
+		dls = append(dls, dl)
+	}
+
+	return dls, rows.Err()
+}
+
+// LoadDeadLetter loads dead letter by row id, returns nil if there is no such dead letter
+func LoadDeadLetter(db *sql.DB, rowID int64) (*DeadLetter, error) {
+	row := db.QueryRow(`
+	SELECT rowid, created_at, updated_at, handler, kind, payload, error, attempts, replayed_at
+	FROM dead_letters
+	WHERE rowid = $1
+	`, rowID)
+
+	dl := &DeadLetter{}
+	err := row.Scan(&dl.RowID, &dl.CreatedAt, &dl.UpdatedAt, &dl.Handler, &dl.Kind, &dl.Payload, &dl.Error, &dl.Attempts, &dl.ReplayedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return dl, nil
+}
+
+// UpdateDeadLetterAttempt records result of replaying dead letter,
+// empty errorText marks dead letter as replayed
+func UpdateDeadLetterAttempt(db *sql.DB, rowID int64, attempts int, errorText string) error {
+	_, err := db.Exec(`
+	UPDATE dead_letters SET
+		updated_at = datetime("now"),
+		attempts = attempts + $1,
+		error = CASE WHEN $2 = '' THEN error ELSE $2 END,
+		replayed_at = CASE WHEN $2 = '' THEN datetime("now") ELSE '' END
+	WHERE rowid = $3
+	`, attempts, errorText, rowID)
+
+	return err
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"database/sql"
+)
+
+// Actions of digest events
+const (
+	DigestActionOpened    = "opened"
+	DigestActionClosed    = "closed"
+	DigestActionReopened  = "reopened"
+	DigestActionMerged    = "merged"
+	DigestActionCommented = "commented"
+	DigestActionReviewed  = "reviewed"
+)
+
+// DigestEvent stores event until it is posted in digest to chat
+type DigestEvent struct {
+	RowID     int64
+	CreatedAt string
+	ChatID    int64
+	Schedule  string
+	// Identifies event, so that retried deliveries are counted once
+	Key         string
+	Repo        string
+	Number      int64
+	PullRequest bool
+	Title       string
+	URL         string
+	Action      string
+}
+
+// PendingDigest is a chat and schedule with events waiting for digest
+type PendingDigest struct {
+	ChatID   int64
+	Schedule string
+	// Time of the oldest waiting event
+	Since string
+}
+
+// SaveDigestEvent saves event for digest, event with the same key is saved once
+func SaveDigestEvent(db *sql.DB, e DigestEvent) error {
+	_, err := db.Exec(`
+	INSERT OR IGNORE INTO digest_events(created_at, chat_id, schedule, event_key, repo, number, pull_request, title, url, action) VALUES(
+		datetime("now"),
+		$1,
+		$2,
+		$3,
+		$4,
+		$5,
+		$6,
+		$7,
+		$8,
+		$9
+	)
+	`, e.ChatID, e.Schedule, e.Key, e.Repo, e.Number, e.PullRequest, e.Title, e.URL, e.Action)
+
+	return err
+}
+
+// ListPendingDigests lists chats and schedules with waiting events
+func ListPendingDigests(db *sql.DB) ([]PendingDigest, error) {
+	rows, err := db.Query(`
+	SELECT chat_id, schedule, MIN(created_at)
+	FROM digest_events
+	GROUP BY chat_id, schedule
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pending []PendingDigest
+	for rows.Next() {
+		var p PendingDigest
+		err = rows.Scan(&p.ChatID, &p.Schedule, &p.Since)
+		if err != nil {
+			return nil, err
+		}
+		pending = append(pending, p)
+	}
+
+	return pending, rows.Err()
+}
+
+// ListDigestEvents lists events for chat and schedule saved before given time, oldest first
+func ListDigestEvents(db *sql.DB, chatID int64, schedule string, before string) ([]DigestEvent, error) {
+	rows, err := db.Query(`
+	SELECT rowid, created_at, chat_id, schedule, event_key, repo, number, pull_request, title, url, action
+	FROM digest_events
+	WHERE chat_id = $1 AND schedule = $2 AND created_at < $3
+	ORDER BY rowid
+	`, chatID, schedule, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []DigestEvent
+	for rows.Next() {
+		var e DigestEvent
+		err = rows.Scan(&e.RowID, &e.CreatedAt, &e.ChatID, &e.Schedule, &e.Key, &e.Repo, &e.Number, &e.PullRequest, &e.Title, &e.URL, &e.Action)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+// DeleteDigestEvents deletes events with given row IDs after they were posted
+func DeleteDigestEvents(db *sql.DB, rowIDs []int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, rowID := range rowIDs {
+		_, err = tx.Exec(`
+		DELETE FROM digest_events
+		WHERE rowid = $1
+		`, rowID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"database/sql"
+)
+
+// Identity links Telegram user to Github account
+// Github token is stored as is, so DB file should be readable by bot only
+type Identity struct {
+	TelegramUserID   int64
+	TelegramUserName string
+	GithubLogin      string
+	GithubToken      string
+}
+
+// SaveIdentity saves identity to db, replacing previous identity of the same Telegram user
+func SaveIdentity(db *sql.DB, identity Identity) error {
+	_, err := db.Exec(`
+	INSERT INTO identities(created_at, updated_at, telegram_user_id, telegram_username, github_login, github_token) VALUES(
+		datetime("now"),
+		datetime("now"),
+		$1,
+		$2,
+		$3,
+		$4
+	)
+	ON CONFLICT(telegram_user_id) DO UPDATE SET
+		updated_at = datetime("now"),
+		telegram_username = excluded.telegram_username,
+		github_login = excluded.github_login,
+		github_token = excluded.github_token
+	`, identity.TelegramUserID, identity.TelegramUserName, identity.GithubLogin, identity.GithubToken)
+
+	return err
+}
+
+// FindIdentity finds identity of Telegram user, returns nil if user is not linked
+func FindIdentity(db *sql.DB, telegramUserID int64) (*Identity, error) {
+	row := db.QueryRow(`
+	SELECT telegram_user_id, telegram_username, github_login, github_token
+	FROM identities
+	WHERE telegram_user_id = $1
+	`, telegramUserID)
+
+	identity := &Identity{}
+	err := row.Scan(&identity.TelegramUserID, &identity.TelegramUserName, &identity.GithubLogin, &identity.GithubToken)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+// DeleteIdentity deletes identity of Telegram user, returns false if user was not linked
+func DeleteIdentity(db *sql.DB, telegramUserID int64) (bool, error) {
+	res, err := db.Exec(`
+	DELETE FROM identities
+	WHERE telegram_user_id = $1
+	`, telegramUserID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+
+	return rowsAffected > 0, err
+}
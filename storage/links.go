@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"database/sql"
+)
+
+// Kinds of message links
+const (
+	// LinkKindIssue links a bot message announcing an issue
+	LinkKindIssue = "issue"
+	// LinkKindIssueStatus links a bot message announcing issue status change
+	LinkKindIssueStatus = "issue_status"
+	// LinkKindComment links a bot message relaying a Github comment
+	LinkKindComment = "comment"
+	// LinkKindReviewComment links a bot message relaying a pull request review comment
+	LinkKindReviewComment = "review_comment"
+	// LinkKindReply links a Telegram reply to the Github comment created from it
+	LinkKindReply = "reply"
+	// LinkKindReviewReply links a Telegram reply to the pull request review comment created from it
+	LinkKindReviewReply = "review_reply"
+)
+
+// MessageLink links a Telegram message to a Github issue or comment
+type MessageLink struct {
+	Kind string
+	// Tracker linked issue or comment is from, comment IDs are unique within tracker and kind only
+	Source      string
+	ChatID      int64
+	MessageID   int64
+	Owner       string
+	Repo        string
+	IssueNumber int64
+	IssueURL    string
+	CommentID   int64
+	URL         string
+	Author      string
+	AuthorURL   string
+	Title       string
+	Body        string
+}
+
+const messageLinkColumns = `
+	kind, chat_id, message_id, owner, repo, issue_number, issue_url,
+	comment_id, url, author, author_url, title, body, source
+`
+
+// SaveMessageLink saves message link to db, replacing the previous link
+// for the same message if there was one
+func SaveMessageLink(db *sql.DB, link MessageLink) error {
+	_, err := db.Exec(`
+	INSERT OR REPLACE INTO message_links(created_at, `+messageLinkColumns+`) VALUES(
+		datetime("now"),
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+	)
+	`,
+		link.Kind, link.ChatID, link.MessageID, link.Owner, link.Repo, link.IssueNumber, link.IssueURL,
+		link.CommentID, link.URL, link.Author, link.AuthorURL, link.Title, link.Body, link.Source,
+	)
+
+	return err
+}
+
+// FindMessageLink finds link for a Telegram message, returns nil if message is not linked
+func FindMessageLink(db *sql.DB, chatID int64, messageID int64) (*MessageLink, error) {
+	row := db.QueryRow(`
+	SELECT `+messageLinkColumns+`
+	FROM message_links
+	WHERE chat_id = $1 AND message_id = $2
+	`, chatID, messageID)
+
+	return scanMessageLink(row)
+}
+
+// FindMessageLinkByCommentID finds link of given kind for a comment from source tracker,
+// returns nil if comment is not linked
+func FindMessageLinkByCommentID(db *sql.DB, source string, kind string, commentID int64) (*MessageLink, error) {
+	row := db.QueryRow(`
+	SELECT `+messageLinkColumns+`
+	FROM message_links
+	WHERE source = $1 AND kind = $2 AND comment_id = $3
+	ORDER BY rowid
+	LIMIT 1
+	`, source, kind, commentID)
+
+	return scanMessageLink(row)
+}
+
+// FindIssueMessageLinks finds links of given kind for an issue from source tracker in all chats, oldest first
+func FindIssueMessageLinks(db *sql.DB, source string, kind string, owner string, repo string, issueNumber int64) ([]MessageLink, error) {
+	rows, err := db.Query(`
+	SELECT `+messageLinkColumns+`
+	FROM message_links
+	WHERE source = $1 AND kind = $2 AND owner = $3 AND repo = $4 AND issue_number = $5
+	ORDER BY rowid
+	`, source, kind, owner, repo, issueNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanMessageLinks(rows)
+}
+
+// FindCommentMessageLinks finds links of given kind for a comment from source tracker in all chats, oldest first
+func FindCommentMessageLinks(db *sql.DB, source string, kind string, commentID int64) ([]MessageLink, error) {
+	rows, err := db.Query(`
+	SELECT `+messageLinkColumns+`
+	FROM message_links
+	WHERE source = $1 AND kind = $2 AND comment_id = $3
+	ORDER BY rowid
+	`, source, kind, commentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanMessageLinks(rows)
+}
+
+// DeleteMessageLink deletes link of a Telegram message
+func DeleteMessageLink(db *sql.DB, chatID int64, messageID int64) error {
+	_, err := db.Exec(`
+	DELETE FROM message_links
+	WHERE chat_id = $1 AND message_id = $2
+	`, chatID, messageID)
+
+	return err
+}
+
+// DeleteCommentMessageLinks deletes all links of given kind for a comment from source tracker
+func DeleteCommentMessageLinks(db *sql.DB, source string, kind string, commentID int64) error {
+	_, err := db.Exec(`
+	DELETE FROM message_links
+	WHERE source = $1 AND kind = $2 AND comment_id = $3
+	`, source, kind, commentID)
+
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanMessageLink(row scanner) (*MessageLink, error) {
+	link := &MessageLink{}
+	err := row.Scan(
+		&link.Kind, &link.ChatID, &link.MessageID, &link.Owner, &link.Repo, &link.IssueNumber, &link.IssueURL,
+		&link.CommentID, &link.URL, &link.Author, &link.AuthorURL, &link.Title, &link.Body, &link.Source,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+func scanMessageLinks(rows *sql.Rows) ([]MessageLink, error) {
+	var links []MessageLink
+	for rows.Next() {
+		link, err := scanMessageLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, *link)
+	}
+
+	return links, rows.Err()
+}
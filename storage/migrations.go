@@ -19,6 +19,150 @@ var migrations = []string{
 		visible_at TEXT DEFAULT '' NOT NULL
 	);
 	`,
+	// 2
+	`
+	CREATE TABLE message_links(
+		created_at TEXT DEFAULT '' NOT NULL,
+		source TEXT DEFAULT '' NOT NULL,
+		kind TEXT DEFAULT '' NOT NULL,
+		chat_id INTEGER DEFAULT 0 NOT NULL,
+		message_id INTEGER DEFAULT 0 NOT NULL,
+		owner TEXT DEFAULT '' NOT NULL,
+		repo TEXT DEFAULT '' NOT NULL,
+		issue_number INTEGER DEFAULT 0 NOT NULL,
+		issue_url TEXT DEFAULT '' NOT NULL,
+		comment_id INTEGER DEFAULT 0 NOT NULL,
+		url TEXT DEFAULT '' NOT NULL,
+		author TEXT DEFAULT '' NOT NULL,
+		author_url TEXT DEFAULT '' NOT NULL,
+		title TEXT DEFAULT '' NOT NULL,
+		body TEXT DEFAULT '' NOT NULL
+	);
+	CREATE UNIQUE INDEX message_links_message ON message_links(chat_id, message_id);
+	CREATE INDEX message_links_comment ON message_links(source, kind, comment_id);
+	`,
+	// 3
+	`
+	CREATE TABLE dead_letters(
+		created_at TEXT DEFAULT '' NOT NULL,
+		updated_at TEXT DEFAULT '' NOT NULL,
+		handler TEXT DEFAULT '' NOT NULL,
+		kind TEXT DEFAULT '' NOT NULL,
+		payload TEXT DEFAULT '' NOT NULL,
+		error TEXT DEFAULT '' NOT NULL,
+		attempts INTEGER DEFAULT 0 NOT NULL,
+		replayed_at TEXT DEFAULT '' NOT NULL
+	);
+	`,
+	// 4
+	`
+	CREATE TABLE events_queue(
+		created_at TEXT DEFAULT '' NOT NULL,
+		updated_at TEXT DEFAULT '' NOT NULL,
+		source TEXT DEFAULT '' NOT NULL,
+		source_id TEXT DEFAULT '' NOT NULL,
+		meta TEXT DEFAULT '' NOT NULL,
+		payload TEXT DEFAULT '' NOT NULL,
+		visible_at TEXT DEFAULT '' NOT NULL,
+		attempts INTEGER DEFAULT 0 NOT NULL
+	);
+	CREATE UNIQUE INDEX events_queue_source_id ON events_queue(source, source_id) WHERE source_id != '';
+	CREATE INDEX events_queue_visible_at ON events_queue(visible_at);
+
+	INSERT INTO events_queue(created_at, updated_at, source, meta, payload, visible_at)
+	SELECT created_at, updated_at, 'webhook:' || path, headers, body, visible_at
+	FROM webhooks_data;
+
+	DROP TABLE webhooks_data;
+
+	CREATE TABLE source_offsets(
+		updated_at TEXT DEFAULT '' NOT NULL,
+		source TEXT PRIMARY KEY NOT NULL,
+		next_offset INTEGER DEFAULT 0 NOT NULL
+	);
+	`,
+	// 5
+	`
+	CREATE INDEX message_links_issue ON message_links(owner, repo, issue_number);
+	`,
+	// 6
+	`
+	CREATE TABLE identities(
+		created_at TEXT DEFAULT '' NOT NULL,
+		updated_at TEXT DEFAULT '' NOT NULL,
+		telegram_user_id INTEGER PRIMARY KEY NOT NULL,
+		telegram_username TEXT DEFAULT '' NOT NULL,
+		github_login TEXT DEFAULT '' NOT NULL,
+		github_token TEXT DEFAULT '' NOT NULL
+	);
+	`,
+	// 7
+	`
+	CREATE TABLE audit_log(
+		created_at TEXT DEFAULT '' NOT NULL,
+		telegram_user_id INTEGER DEFAULT 0 NOT NULL,
+		telegram_username TEXT DEFAULT '' NOT NULL,
+		chat_id INTEGER DEFAULT 0 NOT NULL,
+		repo TEXT DEFAULT '' NOT NULL,
+		action TEXT DEFAULT '' NOT NULL,
+		role TEXT DEFAULT '' NOT NULL,
+		required_role TEXT DEFAULT '' NOT NULL,
+		allowed INTEGER DEFAULT 0 NOT NULL,
+		event_source TEXT DEFAULT '' NOT NULL,
+		event_id TEXT DEFAULT '' NOT NULL
+	);
+	CREATE UNIQUE INDEX audit_log_event ON audit_log(event_source, event_id, action) WHERE event_id != '';
+	`,
+	// 8
+	`
+	CREATE TABLE digest_events(
+		created_at TEXT DEFAULT '' NOT NULL,
+		chat_id INTEGER DEFAULT 0 NOT NULL,
+		schedule TEXT DEFAULT '' NOT NULL,
+		event_key TEXT DEFAULT '' NOT NULL,
+		repo TEXT DEFAULT '' NOT NULL,
+		number INTEGER DEFAULT 0 NOT NULL,
+		pull_request INTEGER DEFAULT 0 NOT NULL,
+		title TEXT DEFAULT '' NOT NULL,
+		url TEXT DEFAULT '' NOT NULL,
+		action TEXT DEFAULT '' NOT NULL,
+		UNIQUE(chat_id, schedule, event_key)
+	);
+	`,
+	// 9
+	`
+	CREATE TABLE issue_topics(
+		created_at TEXT DEFAULT '' NOT NULL,
+		chat_id INTEGER DEFAULT 0 NOT NULL,
+		owner TEXT DEFAULT '' NOT NULL,
+		repo TEXT DEFAULT '' NOT NULL,
+		issue_number INTEGER DEFAULT 0 NOT NULL,
+		thread_id INTEGER DEFAULT 0 NOT NULL,
+		UNIQUE(chat_id, owner, repo, issue_number)
+	);
+	`,
+	// 10
+	`
+	CREATE TABLE processed_events(
+		processed_at TEXT DEFAULT '' NOT NULL,
+		source TEXT DEFAULT '' NOT NULL,
+		source_id TEXT DEFAULT '' NOT NULL,
+		PRIMARY KEY(source, source_id)
+	);
+	CREATE INDEX processed_events_processed_at ON processed_events(processed_at);
+	`,
+	// 11
+	`
+	CREATE TABLE sent_parts(
+		created_at TEXT DEFAULT '' NOT NULL,
+		delivery TEXT DEFAULT '' NOT NULL,
+		chat_id INTEGER DEFAULT 0 NOT NULL,
+		part INTEGER DEFAULT 0 NOT NULL,
+		message_id INTEGER DEFAULT 0 NOT NULL,
+		PRIMARY KEY(delivery, chat_id, part)
+	);
+	CREATE INDEX sent_parts_created_at ON sent_parts(created_at);
+	`,
 }
 
 func applyMigrations(db *sql.DB) {
@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// QueueItem stores incoming event data until it is processed
+type QueueItem struct {
+	RowID int64
+	// Source of event, e.g. "telegram" or "webhook:/github"
+	Source string
+	// ID of event in source, used to skip duplicates, empty means no deduplication
+	SourceID string
+	// Source specific metadata, e.g. webhook headers
+	Meta    string
+	Payload string
+	// Failed attempts to process item
+	Attempts int
+}
+
+// EnqueueEvent saves incoming event data to queue,
+// returns false if event with the same source ID is already queued or was recently processed
+func EnqueueEvent(db *sql.DB, item QueueItem) (bool, error) {
+	return enqueueEvent(db, item)
+}
+
+// EnqueueEventWithOffset saves incoming event data to queue and advances source offset atomically,
+// returns false if event with the same source ID is already queued or was recently processed
+func EnqueueEventWithOffset(db *sql.DB, item QueueItem, offset int64) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	queued, err := enqueueEvent(tx, item)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(`
+	INSERT OR REPLACE INTO source_offsets(updated_at, source, next_offset) VALUES(
+		datetime("now"),
+		$1,
+		$2
+	)
+	`, item.Source, offset)
+	if err != nil {
+		return false, err
+	}
+
+	return queued, tx.Commit()
+}
+
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func enqueueEvent(db execer, item QueueItem) (bool, error) {
+	res, err := db.Exec(`
+	INSERT OR IGNORE INTO events_queue(created_at, updated_at, source, source_id, meta, payload, visible_at)
+	SELECT
+		datetime("now"),
+		datetime("now"),
+		$1,
+		$2,
+		$3,
+		$4,
+		datetime("now")
+	WHERE $2 = '' OR NOT EXISTS (
+		SELECT 1
+		FROM processed_events
+		WHERE source = $1 AND source_id = $2
+	)
+	`, item.Source, item.SourceID, item.Meta, item.Payload)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// LoadSourceOffset loads offset saved with EnqueueEventWithOffset, returns 0 if there is none
+func LoadSourceOffset(db *sql.DB, source string) (int64, error) {
+	var offset int64
+	err := db.QueryRow(`
+	SELECT next_offset
+	FROM source_offsets
+	WHERE source = $1
+	`, source).Scan(&offset)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	return offset, err
+}
+
+// LoadQueueItem loads visible queue item and hides it from others for 30 seconds,
+// returns nil if there are no visible items
+func LoadQueueItem(db *sql.DB) (*QueueItem, error) {
+	// 1. Find "visible" row id
+	row := db.QueryRow(`
+			SELECT rowid, source, source_id, meta, payload, attempts, visible_at
+			FROM events_queue
+			WHERE visible_at <= datetime("now")
+			ORDER BY rowid
+			LIMIT 1
+			`)
+
+	item := &QueueItem{}
+	var visibleAt string
+
+	err := row.Scan(&item.RowID, &item.Source, &item.SourceID, &item.Meta, &item.Payload, &item.Attempts, &visibleAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Try to "lock" it from others by advancing visible_at
+	// This will not succeed if somebody else has already done the same.
+	res, err := db.Exec(`
+			UPDATE events_queue SET
+				updated_at = datetime("now"),
+				visible_at = datetime("now", "+30 seconds")
+			WHERE rowid = $1 AND visible_at = $2
+			`, item.RowID, visibleAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	// Somebody else has already grabbed this row, skip this attempt
+	if rowsAffected == 0 {
+		return nil, nil
+	}
+
+	return item, nil
+}
+
+// ReleaseQueueItem makes queue item locked by LoadQueueItem visible again after delay without counting failed attempt
+func ReleaseQueueItem(db *sql.DB, rowID int64, delay time.Duration) error {
+	_, err := db.Exec(`
+	UPDATE events_queue SET
+		updated_at = datetime("now"),
+		visible_at = datetime("now", $2)
+	WHERE rowid = $1
+	`, rowID, fmt.Sprintf("+%d seconds", int64(delay.Seconds())))
+
+	return err
+}
+
+// RetryQueueItem makes queue item locked by LoadQueueItem visible again after delay and counts failed attempt
+func RetryQueueItem(db *sql.DB, rowID int64, delay time.Duration) error {
+	_, err := db.Exec(`
+	UPDATE events_queue SET
+		updated_at = datetime("now"),
+		visible_at = datetime("now", $2),
+		attempts = attempts + 1
+	WHERE rowid = $1
+	`, rowID, fmt.Sprintf("+%d seconds", int64(delay.Seconds())))
+
+	return err
+}
+
+// CompleteQueueItem deletes queue item and, if it was processed successfully, remembers its source ID,
+// so that its redeliveries are not queued again
+func CompleteQueueItem(db *sql.DB, item QueueItem, processed bool) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+	DELETE FROM events_queue
+	WHERE rowid = $1
+	`, item.RowID)
+	if err != nil {
+		return err
+	}
+
+	if processed && item.SourceID != "" {
+		_, err = tx.Exec(`
+		INSERT OR REPLACE INTO processed_events(processed_at, source, source_id) VALUES(
+			datetime("now"),
+			$1,
+			$2
+		)
+		`, item.Source, item.SourceID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteProcessedEvents forgets source IDs of events processed longer than retention ago,
+// returns number of forgotten IDs
+func DeleteProcessedEvents(db *sql.DB, retention time.Duration) (int64, error) {
+	res, err := db.Exec(`
+	DELETE FROM processed_events
+	WHERE processed_at < datetime("now", $1)
+	`, fmt.Sprintf("-%d seconds", int64(retention.Seconds())))
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
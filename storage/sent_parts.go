@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SaveSentPart records that part of message was sent to chat while handling delivery,
+// so that it is not sent again when handling is retried
+func SaveSentPart(db *sql.DB, delivery string, chatID int64, part int, messageID int64) error {
+	_, err := db.Exec(`
+	INSERT OR REPLACE INTO sent_parts(created_at, delivery, chat_id, part, message_id) VALUES(
+		datetime("now"),
+		$1,
+		$2,
+		$3,
+		$4
+	)
+	`, delivery, chatID, part, messageID)
+
+	return err
+}
+
+// FindSentParts finds message IDs of parts sent to chat while handling delivery by part index
+func FindSentParts(db *sql.DB, delivery string, chatID int64) (map[int]int64, error) {
+	rows, err := db.Query(`
+	SELECT part, message_id
+	FROM sent_parts
+	WHERE delivery = $1 AND chat_id = $2
+	`, delivery, chatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	parts := make(map[int]int64)
+	for rows.Next() {
+		var part int
+		var messageID int64
+		err = rows.Scan(&part, &messageID)
+		if err != nil {
+			return nil, err
+		}
+		parts[part] = messageID
+	}
+
+	return parts, rows.Err()
+}
+
+// DeleteSentParts forgets parts sent longer than retention ago, returns number of forgotten parts
+func DeleteSentParts(db *sql.DB, retention time.Duration) (int64, error) {
+	res, err := db.Exec(`
+	DELETE FROM sent_parts
+	WHERE created_at < datetime("now", $1)
+	`, fmt.Sprintf("-%d seconds", int64(retention.Seconds())))
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"database/sql"
+)
+
+// IssueTopic links issue or pull request to forum topic created for it in chat
+type IssueTopic struct {
+	ChatID      int64
+	Owner       string
+	Repo        string
+	IssueNumber int64
+	ThreadID    int64
+}
+
+// SaveIssueTopic saves forum topic of issue
+func SaveIssueTopic(db *sql.DB, topic IssueTopic) error {
+	_, err := db.Exec(`
+	INSERT OR REPLACE INTO issue_topics(created_at, chat_id, owner, repo, issue_number, thread_id) VALUES(
+		datetime("now"),
+		$1,
+		$2,
+		$3,
+		$4,
+		$5
+	)
+	`, topic.ChatID, topic.Owner, topic.Repo, topic.IssueNumber, topic.ThreadID)
+
+	return err
+}
+
+// FindIssueTopics finds forum topics of issue in all chats
+func FindIssueTopics(db *sql.DB, owner string, repo string, issueNumber int64) ([]IssueTopic, error) {
+	rows, err := db.Query(`
+	SELECT chat_id, owner, repo, issue_number, thread_id
+	FROM issue_topics
+	WHERE owner = $1 AND repo = $2 AND issue_number = $3
+	`, owner, repo, issueNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var topics []IssueTopic
+	for rows.Next() {
+		var t IssueTopic
+		err = rows.Scan(&t.ChatID, &t.Owner, &t.Repo, &t.IssueNumber, &t.ThreadID)
+		if err != nil {
+			return nil, err
+		}
+		topics = append(topics, t)
+	}
+
+	return topics, rows.Err()
+}
@@ -0,0 +1,355 @@
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/andreyst/tracker-messenger-bridge/config"
+	"github.com/andreyst/tracker-messenger-bridge/markdown"
+)
+
+// Keys of message templates, event type and action
+const (
+	IssueOpened                     = "issues.opened"
+	IssueClosed                     = "issues.closed"
+	IssueReopened                   = "issues.reopened"
+	IssueLabeled                    = "issues.labeled"
+	IssueUnlabeled                  = "issues.unlabeled"
+	IssueAssigned                   = "issues.assigned"
+	IssueUnassigned                 = "issues.unassigned"
+	IssueCommentCreated             = "issue_comment.created"
+	IssueCommentDeleted             = "issue_comment.deleted"
+	PullRequestOpened               = "pull_request.opened"
+	PullRequestClosed               = "pull_request.closed"
+	PullRequestMerged               = "pull_request.merged"
+	PullRequestReopened             = "pull_request.reopened"
+	PullRequestLinked               = "pull_request.linked"
+	PullRequestReviewSubmitted      = "pull_request_review.submitted"
+	PullRequestReviewCommentCreated = "pull_request_review_comment.created"
+	PullRequestReviewCommentDeleted = "pull_request_review_comment.deleted"
+)
+
+// Templates output MarkdownV2, so literal text has to escape _*[]()~`>#+-=|{}.! with backslash
+var defaults = map[string]string{
+	IssueOpened:     `New issue: \#{{ .Number }} {{ link .Title .URL }} by {{ user .Author .AuthorURL }}` + "\nDescription:\n{{ body }}",
+	IssueClosed:     `✅ Closed: \#{{ .Number }} {{ link .Title .URL }} by {{ user .Sender .SenderURL }}`,
+	IssueReopened:   `🔄 Reopened: \#{{ .Number }} {{ link .Title .URL }} by {{ user .Sender .SenderURL }}`,
+	IssueLabeled:    `🏷 Label {{ code .Label }} added: \#{{ .Number }} {{ link .Title .URL }} by {{ user .Sender .SenderURL }}`,
+	IssueUnlabeled:  `🏷 Label {{ code .Label }} removed: \#{{ .Number }} {{ link .Title .URL }} by {{ user .Sender .SenderURL }}`,
+	IssueAssigned:   `👤 Assigned to {{ user .Assignee .AssigneeURL }}: \#{{ .Number }} {{ link .Title .URL }} by {{ user .Sender .SenderURL }}`,
+	IssueUnassigned: `👤 Unassigned from {{ user .Assignee .AssigneeURL }}: \#{{ .Number }} {{ link .Title .URL }} by {{ user .Sender .SenderURL }}`,
+
+	IssueCommentCreated: `Comment on \#{{ .Number }} {{ link .Title .URL }} by {{ user .Author .AuthorURL }}:` + "\n{{ body }}",
+	IssueCommentDeleted: `🗑 Comment on \#{{ .Number }} {{ link .Title .URL }} by {{ user .Author .AuthorURL }} was deleted`,
+
+	PullRequestOpened:   `New pull request: \#{{ .Number }} {{ link .Title .URL }} by {{ user .Sender .SenderURL }}` + "\nDescription:\n{{ body }}",
+	PullRequestClosed:   `Pull request closed: \#{{ .Number }} {{ link .Title .URL }} by {{ user .Sender .SenderURL }}`,
+	PullRequestMerged:   `Pull request merged: \#{{ .Number }} {{ link .Title .URL }} by {{ user .Sender .SenderURL }}`,
+	PullRequestReopened: `Pull request reopened: \#{{ .Number }} {{ link .Title .URL }} by {{ user .Sender .SenderURL }}`,
+	// Posted to threads of issues pull request closes
+	PullRequestLinked: `🔗 Pull request \#{{ .Number }} {{ link .Title .URL }} by {{ user .Author .AuthorURL }} closes this issue`,
+
+	PullRequestReviewSubmitted: `{{ link "Review" .CommentURL }} on \#{{ .Number }} {{ link .Title .URL }} by {{ user .Author .AuthorURL }}: {{ escape .State }}` +
+		"{{ if .Body }}\n{{ body }}{{ end }}",
+
+	PullRequestReviewCommentCreated: `{{ link "Review comment" .CommentURL }} on \#{{ .Number }} {{ link .Title .URL }} by {{ user .Author .AuthorURL }} at {{ code .Path }}:` +
+		"\n{{ body }}",
+	PullRequestReviewCommentDeleted: `🗑 Review comment on \#{{ .Number }} {{ link .Title .URL }} by {{ user .Author .AuthorURL }} was deleted`,
+}
+
+// Data - values available in message templates
+type Data struct {
+	// owner/repo
+	Repo string
+	// Issue or pull request message is about
+	Number int64
+	Title  string
+	URL    string
+	Labels []string
+	// Author of issue, pull request, comment or review and their tracker profile URL
+	Author    string
+	AuthorURL string
+	// User who triggered event
+	Sender    string
+	SenderURL string
+	// Github Markdown text of issue, pull request, comment or review, placed with {{ body }}
+	Body string
+	// Comment or review URL
+	CommentURL string
+	// Label added or removed
+	Label string
+	// User assigned or unassigned
+	Assignee    string
+	AssigneeURL string
+	// Review state, e.g. "approved"
+	State string
+	// File path review comment is on
+	Path string
+}
+
+// Message - rendered message, Body goes between MarkdownV2 Header and Footer
+// and is rendered from Github Markdown when message is split into parts
+type Message struct {
+	Header string
+	Body   string
+	Footer string
+}
+
+// bodyMarker - output of body function, it cannot appear in rendered Markdown
+const bodyMarker = "\x00body\x00"
+
+// newFuncs - returns functions available in templates, Markdown is rendered with renderer
+func newFuncs(renderer markdown.Renderer) template.FuncMap {
+	return template.FuncMap{
+		// Escapes MarkdownV2 special characters in text
+		"escape": markdown.Escape,
+		// Renders Github Markdown as MarkdownV2, long text is not split, see body
+		"markdown": renderer.Render,
+		// Renders link with escaped text
+		"link": markdown.Link,
+		// Renders login linked to tracker profile, e.g. {{ user .Author .AuthorURL }}, login without profile URL is not linked
+		"user": func(login string, profileURL ...string) string {
+			if len(profileURL) == 0 || profileURL[0] == "" {
+				return markdown.Escape(login)
+			}
+			return markdown.Link(login, profileURL[0])
+		},
+		// Renders inline code
+		"code": func(text string) string {
+			return "`" + markdown.EscapeCode(text) + "`"
+		},
+		// Renders labels as inline code badges separated by spaces
+		"labels": func(labels []string) string {
+			badges := make([]string, len(labels))
+			for i, label := range labels {
+				badges[i] = "`" + markdown.EscapeCode(label) + "`"
+			}
+			return strings.Join(badges, " ")
+		},
+		// Cuts text to at most n characters, ending with … when cut
+		"truncate": func(n int, text string) string {
+			runes := []rune(text)
+			if n < 1 || len(runes) <= n {
+				return text
+			}
+			return string(runes[:n-1]) + "…"
+		},
+		// Places message body, which is split into several messages when too long
+		"body": func() string {
+			return bodyMarker
+		},
+	}
+}
+
+// sample - data templates are executed with to validate them
+var sample = Data{
+	Repo:        "owner/repo",
+	Number:      1,
+	Title:       "Title",
+	URL:         "https://github.com/owner/repo/issues/1",
+	Labels:      []string{"bug"},
+	Author:      "author",
+	AuthorURL:   "https://github.com/author",
+	Sender:      "sender",
+	SenderURL:   "https://github.com/sender",
+	Body:        "Body",
+	CommentURL:  "https://github.com/owner/repo/issues/1#issuecomment-1",
+	Label:       "bug",
+	Assignee:    "assignee",
+	AssigneeURL: "https://github.com/assignee",
+	State:       "approved",
+	Path:        "main.go",
+}
+
+// Set - message templates, defaults can be overridden for repos or chats
+// Overrides can be replaced at runtime with SetOverrides or Replace
+type Set struct {
+	mutex     sync.RWMutex
+	funcs     template.FuncMap
+	defaults  map[string]*template.Template
+	overrides []override
+}
+
+type override struct {
+	repos     []string
+	chats     []int64
+	templates map[string]*template.Template
+}
+
+// NewSet - creates set of default templates with overrides, Markdown in templates is rendered with renderer
+func NewSet(overrides []config.TemplateSet, renderer markdown.Renderer) (*Set, error) {
+	s := &Set{funcs: newFuncs(renderer), defaults: make(map[string]*template.Template)}
+	for key, text := range defaults {
+		tmpl, err := s.parse(key, text)
+		if err != nil {
+			return nil, err
+		}
+		s.defaults[key] = tmpl
+	}
+
+	err := s.SetOverrides(overrides)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// SetOverrides - parses, validates and replaces overrides of default templates
+func (s *Set) SetOverrides(sets []config.TemplateSet) error {
+	errs := config.ValidateTemplateSets(sets)
+
+	overrides := make([]override, len(sets))
+	for i, set := range sets {
+		overrides[i] = override{
+			repos:     set.Repos,
+			chats:     set.Chats,
+			templates: make(map[string]*template.Template),
+		}
+		keys := make([]string, 0, len(set.Messages))
+		for key := range set.Messages {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			text := set.Messages[key]
+			if _, ok := defaults[key]; !ok {
+				errs = append(errs, fmt.Sprintf("templates[%d].messages: unknown template %q, expected one of %s", i, key, strings.Join(Keys(), ", ")))
+				continue
+			}
+			tmpl, err := s.parse(key, text)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("templates[%d].messages: %v", i, err))
+				continue
+			}
+			overrides[i].templates[key] = tmpl
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.overrides = overrides
+
+	return nil
+}
+
+// Replace - replaces overrides with those of other set, e.g. built from reloaded config
+func (s *Set) Replace(other *Set) {
+	other.mutex.RLock()
+	overrides := other.overrides
+	other.mutex.RUnlock()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.overrides = overrides
+}
+
+// Keys - returns sorted keys of all templates
+func Keys() []string {
+	keys := make([]string, 0, len(defaults))
+	for key := range defaults {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Render - renders template with key for chat and repo of data
+func (s *Set) Render(key string, chatID int64, data Data) (Message, error) {
+	tmpl, err := s.lookup(key, chatID, data.Repo)
+	if err != nil {
+		return Message{}, err
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	if err != nil {
+		return Message{}, fmt.Errorf("unable to render template %s: %w", key, err)
+	}
+
+	// Body is placed once, repeated placeholders are dropped
+	parts := strings.SplitN(buf.String(), bodyMarker, 2)
+	if len(parts) == 1 {
+		return Message{Header: parts[0]}, nil
+	}
+
+	return Message{
+		Header: parts[0],
+		Body:   data.Body,
+		Footer: strings.Replace(parts[1], bodyMarker, "", -1),
+	}, nil
+}
+
+func (s *Set) lookup(key string, chatID int64, repo string) (*template.Template, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, o := range s.overrides {
+		tmpl, ok := o.templates[key]
+		if ok && o.matches(chatID, repo) {
+			return tmpl, nil
+		}
+	}
+
+	tmpl, ok := s.defaults[key]
+	if !ok {
+		return nil, fmt.Errorf("unknown template %s", key)
+	}
+
+	return tmpl, nil
+}
+
+func (o override) matches(chatID int64, repo string) bool {
+	if len(o.chats) > 0 {
+		found := false
+		for _, id := range o.chats {
+			if id == chatID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(o.repos) == 0 {
+		return true
+	}
+	for _, pattern := range o.repos {
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(repo)); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parse - parses template and executes it with sample data, so that unknown fields are reported at startup
+func (s *Set) parse(key string, text string) (*template.Template, error) {
+	tmpl, err := template.New(key).Funcs(s.funcs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tmpl.Execute(ioutil.Discard, sample)
+	if err != nil {
+		return nil, err
+	}
+
+	return tmpl, nil
+}
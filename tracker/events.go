@@ -0,0 +1,148 @@
+package tracker
+
+import (
+	"time"
+)
+
+// Actions of issue events
+const (
+	ActionOpened     = "opened"
+	ActionEdited     = "edited"
+	ActionClosed     = "closed"
+	ActionReopened   = "reopened"
+	ActionLabeled    = "labeled"
+	ActionUnlabeled  = "unlabeled"
+	ActionAssigned   = "assigned"
+	ActionUnassigned = "unassigned"
+)
+
+// Actions of comment events
+const (
+	ActionCreated = "created"
+	ActionDeleted = "deleted"
+)
+
+// Actions of review events
+const (
+	ActionSubmitted = "submitted"
+)
+
+// States of submitted reviews
+const (
+	ReviewApproved         = "approved"
+	ReviewChangesRequested = "changes_requested"
+	ReviewCommented        = "commented"
+)
+
+// Kinds of event envelopes with tracker event payloads
+const (
+	KindIssue         = "tracker.issue"
+	KindComment       = "tracker.comment"
+	KindPullRequest   = "tracker.pull_request"
+	KindReview        = "tracker.review"
+	KindReviewComment = "tracker.review_comment"
+)
+
+// IssueEvent - issue or pull request was opened, updated or closed
+type IssueEvent struct {
+	// One of Action* constants, trackers may send other actions too
+	Action string
+	Repo   Repo
+	Issue  Issue
+	Sender User
+	// Label added or removed by labeled and unlabeled actions, empty when tracker does not tell
+	Label string
+	// User assigned or unassigned by assigned and unassigned actions
+	Assignee User
+}
+
+// CommentEvent - comment on issue or pull request was created, edited or deleted
+type CommentEvent struct {
+	// ActionCreated, ActionEdited or ActionDeleted
+	Action  string
+	Repo    Repo
+	Issue   Issue
+	Comment Comment
+	Sender  User
+}
+
+// PullRequestEvent - pull request was opened, updated or closed
+type PullRequestEvent struct {
+	// One of Action* constants, trackers may send other actions too
+	Action      string
+	Repo        Repo
+	PullRequest Issue
+	// Whether closed pull request was merged
+	Merged bool
+	Sender User
+}
+
+// ReviewEvent - pull request review was submitted
+type ReviewEvent struct {
+	// ActionSubmitted, trackers may send other actions too
+	Action      string
+	Repo        Repo
+	PullRequest Issue
+	Review      Review
+	Sender      User
+}
+
+// ReviewCommentEvent - comment on pull request diff line was created, edited or deleted
+type ReviewCommentEvent struct {
+	// ActionCreated, ActionEdited or ActionDeleted
+	Action      string
+	Repo        Repo
+	PullRequest Issue
+	Comment     Comment
+	Sender      User
+}
+
+// Repo - repository
+type Repo struct {
+	Owner string
+	Name  string
+	// owner/name, as matched by routes and filters
+	FullName string
+}
+
+// Issue - issue or pull request
+type Issue struct {
+	// ID unique within tracker, unlike Number
+	ID          int64
+	Number      int64
+	Title       string
+	Body        string
+	URL         string
+	State       string
+	Author      User
+	Labels      []string
+	PullRequest bool
+	UpdatedAt   time.Time
+}
+
+// Comment - comment on issue or pull request
+type Comment struct {
+	ID     int64
+	Body   string
+	URL    string
+	Author User
+	// File commented on, set for review comments only
+	Path string
+}
+
+// Review - pull request review
+type Review struct {
+	// Zero when tracker does not tell
+	ID int64
+	// One of Review* constants
+	State  string
+	Body   string
+	URL    string
+	Author User
+}
+
+// User - tracker user
+type User struct {
+	Login string
+	URL   string
+}
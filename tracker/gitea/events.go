@@ -0,0 +1,254 @@
+package gitea
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andreyst/tracker-messenger-bridge/tracker"
+)
+
+// Gitea issue actions which differ from normalized ones
+const (
+	actionLabelUpdated = "label_updated"
+	actionLabelCleared = "label_cleared"
+	actionReviewed     = "reviewed"
+)
+
+// reviewStates - review states by suffix of Gitea review type, e.g. "pull_request_review_approved"
+var reviewStates = map[string]string{
+	"approved": tracker.ReviewApproved,
+	"rejected": tracker.ReviewChangesRequested,
+	"comment":  tracker.ReviewCommented,
+}
+
+type user struct {
+	Login   string `json:"login"`
+	HTMLURL string `json:"html_url"`
+}
+
+type repository struct {
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	Owner    user   `json:"owner"`
+}
+
+type issue struct {
+	ID      int64  `json:"id"`
+	Number  int64  `json:"number"`
+	HTMLURL string `json:"html_url"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	State   string `json:"state"`
+	User    user   `json:"user"`
+	Labels  []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Assignee    *user           `json:"assignee"`
+	PullRequest json.RawMessage `json:"pull_request"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+type pullRequest struct {
+	issue
+	Merged bool `json:"merged"`
+}
+
+type review struct {
+	Type    string `json:"type"`
+	Content string `json:"content"`
+}
+
+type comment struct {
+	ID      int64  `json:"id"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+	User    user   `json:"user"`
+}
+
+// VerifySignature - checks X-Gitea-Signature, hex HMAC-SHA256 of body with webhook secret
+func VerifySignature(secret string, header http.Header, body []byte) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(header.Get("X-Gitea-Signature")))
+}
+
+// ParseEvent - converts webhook payload of X-Gitea-Event type to tracker.IssueEvent, tracker.CommentEvent,
+// tracker.PullRequestEvent or tracker.ReviewEvent, other events are skipped
+func ParseEvent(eventType string, payload []byte) (interface{}, bool, error) {
+	switch eventType {
+	case "pull_request":
+		var p struct {
+			Action      string      `json:"action"`
+			PullRequest pullRequest `json:"pull_request"`
+			Repository  repository  `json:"repository"`
+			Sender      user        `json:"sender"`
+		}
+		err := json.Unmarshal(payload, &p)
+		if err != nil {
+			return nil, false, err
+		}
+
+		e := tracker.PullRequestEvent{
+			Action:      p.Action,
+			Repo:        convertRepo(p.Repository),
+			PullRequest: convertIssue(p.PullRequest.issue),
+			Merged:      p.PullRequest.Merged,
+			Sender:      convertUser(p.Sender),
+		}
+		e.PullRequest.PullRequest = true
+
+		return e, true, nil
+	case "pull_request_approved", "pull_request_rejected",
+		"pull_request_review_approved", "pull_request_review_rejected", "pull_request_review_comment":
+		return parseReview(payload)
+
+	case "issues":
+		var p struct {
+			Action     string     `json:"action"`
+			Issue      issue      `json:"issue"`
+			Repository repository `json:"repository"`
+			Sender     user       `json:"sender"`
+		}
+		err := json.Unmarshal(payload, &p)
+		if err != nil {
+			return nil, false, err
+		}
+
+		e := tracker.IssueEvent{
+			Action: p.Action,
+			Repo:   convertRepo(p.Repository),
+			Issue:  convertIssue(p.Issue),
+			Sender: convertUser(p.Sender),
+		}
+		switch p.Action {
+		// Gitea sends whole label set instead of changed label
+		case actionLabelUpdated:
+			e.Action = tracker.ActionLabeled
+		case actionLabelCleared:
+			e.Action = tracker.ActionUnlabeled
+		case tracker.ActionAssigned:
+			if p.Issue.Assignee != nil {
+				e.Assignee = convertUser(*p.Issue.Assignee)
+			}
+		}
+
+		return e, true, nil
+	case "issue_comment", "pull_request_comment":
+		var p struct {
+			Action     string     `json:"action"`
+			Issue      issue      `json:"issue"`
+			Comment    comment    `json:"comment"`
+			Repository repository `json:"repository"`
+			Sender     user       `json:"sender"`
+			IsPull     bool       `json:"is_pull"`
+		}
+		err := json.Unmarshal(payload, &p)
+		if err != nil {
+			return nil, false, err
+		}
+		if eventType == "pull_request_comment" && isReview(payload) {
+			// Gitea sends comment reviews with the same event type as comments
+			return parseReview(payload)
+		}
+
+		e := tracker.CommentEvent{
+			Action: p.Action,
+			Repo:   convertRepo(p.Repository),
+			Issue:  convertIssue(p.Issue),
+			Comment: tracker.Comment{
+				ID:     p.Comment.ID,
+				Body:   p.Comment.Body,
+				URL:    p.Comment.HTMLURL,
+				Author: convertUser(p.Comment.User),
+			},
+			Sender: convertUser(p.Sender),
+		}
+		e.Issue.PullRequest = e.Issue.PullRequest || p.IsPull || eventType == "pull_request_comment"
+
+		return e, true, nil
+	}
+
+	return nil, false, nil
+}
+
+// isReview - checks whether payload is about pull request review
+func isReview(payload []byte) bool {
+	var p struct {
+		Review *review `json:"review"`
+	}
+
+	return json.Unmarshal(payload, &p) == nil && p.Review != nil
+}
+
+// parseReview - converts payload of pull request review, Gitea tells neither review ID nor its URL
+func parseReview(payload []byte) (interface{}, bool, error) {
+	var p struct {
+		Action      string      `json:"action"`
+		PullRequest pullRequest `json:"pull_request"`
+		Review      review      `json:"review"`
+		Repository  repository  `json:"repository"`
+		Sender      user        `json:"sender"`
+	}
+	err := json.Unmarshal(payload, &p)
+	if err != nil {
+		return nil, false, err
+	}
+
+	e := tracker.ReviewEvent{
+		Action:      p.Action,
+		Repo:        convertRepo(p.Repository),
+		PullRequest: convertIssue(p.PullRequest.issue),
+		Review: tracker.Review{
+			State:  p.Review.Type,
+			Body:   p.Review.Content,
+			URL:    p.PullRequest.HTMLURL,
+			Author: convertUser(p.Sender),
+		},
+		Sender: convertUser(p.Sender),
+	}
+	e.PullRequest.PullRequest = true
+	if p.Action == actionReviewed {
+		e.Action = tracker.ActionSubmitted
+	}
+	for suffix, state := range reviewStates {
+		if strings.HasSuffix(p.Review.Type, suffix) {
+			e.Review.State = state
+		}
+	}
+
+	return e, true, nil
+}
+
+func convertRepo(r repository) tracker.Repo {
+	return tracker.Repo{Owner: r.Owner.Login, Name: r.Name, FullName: r.FullName}
+}
+
+func convertIssue(i issue) tracker.Issue {
+	converted := tracker.Issue{
+		ID:          i.ID,
+		Number:      i.Number,
+		Title:       i.Title,
+		Body:        i.Body,
+		URL:         i.HTMLURL,
+		State:       i.State,
+		Author:      convertUser(i.User),
+		PullRequest: len(i.PullRequest) > 0 && string(i.PullRequest) != "null",
+		UpdatedAt:   i.UpdatedAt,
+	}
+	for _, label := range i.Labels {
+		converted.Labels = append(converted.Labels, label.Name)
+	}
+
+	return converted
+}
+
+func convertUser(u user) tracker.User {
+	return tracker.User{Login: u.Login, URL: u.HTMLURL}
+}
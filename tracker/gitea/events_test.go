@@ -0,0 +1,168 @@
+package gitea
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andreyst/tracker-messenger-bridge/tracker"
+)
+
+var (
+	repo  = tracker.Repo{Owner: "team", Name: "bridge", FullName: "team/bridge"}
+	alice = tracker.User{Login: "alice", URL: "https://gitea.example.com/alice"}
+	bob   = tracker.User{Login: "bob", URL: "https://gitea.example.com/bob"}
+	// Pull request as told by review payloads
+	reviewedPullRequest = tracker.Issue{
+		ID:          201,
+		Number:      8,
+		Title:       "Fix crash on empty message",
+		Body:        "Fixes #7",
+		URL:         "https://gitea.example.com/team/bridge/pulls/8",
+		State:       "open",
+		Author:      bob,
+		PullRequest: true,
+		UpdatedAt:   time.Date(2021, 2, 3, 6, 0, 0, 0, time.UTC),
+	}
+)
+
+func TestParseEvent(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventType string
+		file      string
+		want      interface{}
+	}{
+		{
+			name:      "issue labels updated",
+			eventType: "issues",
+			file:      "issues.json",
+			want: tracker.IssueEvent{
+				Action: tracker.ActionLabeled,
+				Repo:   repo,
+				Issue: tracker.Issue{
+					ID:        101,
+					Number:    7,
+					Title:     "Crash on empty message",
+					Body:      "Steps to reproduce",
+					URL:       "https://gitea.example.com/team/bridge/issues/7",
+					State:     "open",
+					Author:    alice,
+					Labels:    []string{"bug", "urgent"},
+					UpdatedAt: time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC),
+				},
+				Sender: bob,
+			},
+		},
+		{
+			name:      "pull request merged",
+			eventType: "pull_request",
+			file:      "pull_request.json",
+			want: tracker.PullRequestEvent{
+				Action: tracker.ActionClosed,
+				Repo:   repo,
+				PullRequest: tracker.Issue{
+					ID:          201,
+					Number:      8,
+					Title:       "Fix crash on empty message",
+					Body:        "Fixes #7",
+					URL:         "https://gitea.example.com/team/bridge/pulls/8",
+					State:       "closed",
+					Author:      bob,
+					Labels:      []string{"bug"},
+					PullRequest: true,
+					UpdatedAt:   time.Date(2021, 2, 4, 10, 0, 0, 0, time.UTC),
+				},
+				Merged: true,
+				Sender: alice,
+			},
+		},
+		{
+			name:      "pull request approved",
+			eventType: "pull_request_approved",
+			file:      "pull_request_approved.json",
+			want: tracker.ReviewEvent{
+				Action:      tracker.ActionSubmitted,
+				Repo:        repo,
+				PullRequest: reviewedPullRequest,
+				Review: tracker.Review{
+					State:  tracker.ReviewApproved,
+					Body:   "LGTM",
+					URL:    "https://gitea.example.com/team/bridge/pulls/8",
+					Author: alice,
+				},
+				Sender: alice,
+			},
+		},
+		{
+			name:      "pull request comment",
+			eventType: "pull_request_comment",
+			file:      "pull_request_comment.json",
+			want: tracker.CommentEvent{
+				Action: tracker.ActionCreated,
+				Repo:   repo,
+				Issue: tracker.Issue{
+					ID:          201,
+					Number:      8,
+					Title:       "Fix crash on empty message",
+					Body:        "Fixes #7",
+					URL:         "https://gitea.example.com/team/bridge/pulls/8",
+					State:       "open",
+					Author:      bob,
+					PullRequest: true,
+					UpdatedAt:   time.Date(2021, 2, 3, 5, 0, 0, 0, time.UTC),
+				},
+				Comment: tracker.Comment{
+					ID:     301,
+					Body:   "Looks good",
+					URL:    "https://gitea.example.com/team/bridge/pulls/8#issuecomment-301",
+					Author: alice,
+				},
+				Sender: alice,
+			},
+		},
+		{
+			name:      "comment review sent as pull request comment",
+			eventType: "pull_request_comment",
+			file:      "pull_request_review_comment.json",
+			want: tracker.ReviewEvent{
+				Action:      tracker.ActionSubmitted,
+				Repo:        repo,
+				PullRequest: reviewedPullRequest,
+				Review: tracker.Review{
+					State:  tracker.ReviewCommented,
+					Body:   "A few nits",
+					URL:    "https://gitea.example.com/team/bridge/pulls/8",
+					Author: alice,
+				},
+				Sender: alice,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := ioutil.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatalf("unable to read %s: %v", tt.file, err)
+			}
+
+			got, ok, err := ParseEvent(tt.eventType, payload)
+			if err != nil || !ok {
+				t.Fatalf("ParseEvent(%q) = %v, %v", tt.eventType, ok, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseEvent(%q) = %+v\nwant %+v", tt.eventType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEventSkipsOtherEvents(t *testing.T) {
+	_, ok, err := ParseEvent("push", []byte(`{"ref": "refs/heads/main"}`))
+	if ok || err != nil {
+		t.Errorf("ParseEvent(push) = %v, %v, want skipped", ok, err)
+	}
+}
@@ -0,0 +1,240 @@
+package gitea
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/andreyst/tracker-messenger-bridge/tracker"
+)
+
+// Name - tracker name
+const Name = "gitea"
+
+// Labels are looked up by pages of this size
+const labelsPageSize = 50
+
+// Client - Gitea implementation of tracker.Tracker with REST API v1
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// Error - Gitea refused request
+type Error struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("gitea %s %s: %d %s", e.Method, e.Path, e.StatusCode, e.Message)
+}
+
+// New - creates client for Gitea at baseURL, e.g. https://gitea.example.com, authenticated with token
+func New(baseURL string, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: time.Minute},
+	}
+}
+
+// Name - returns tracker name
+func (c *Client) Name() string {
+	return Name
+}
+
+// DisplayName - returns tracker name shown to users
+func (c *Client) DisplayName() string {
+	return "Gitea"
+}
+
+// As - returns client of the same Gitea authenticated with token
+func (c *Client) As(token string) tracker.Tracker {
+	return New(c.baseURL, token)
+}
+
+// CurrentUser - returns login of token owner
+func (c *Client) CurrentUser(ctx context.Context) (string, error) {
+	var user struct {
+		Login string `json:"login"`
+	}
+	err := c.do(ctx, "GET", "/user", nil, &user)
+
+	return user.Login, err
+}
+
+// CreateIssue - creates issue
+func (c *Client) CreateIssue(ctx context.Context, owner string, repo string, title string, body string) (int64, string, error) {
+	var issue struct {
+		Number  int64  `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	err := c.do(ctx, "POST", repoPath(owner, repo, "/issues"), map[string]string{
+		"title": title,
+		"body":  body,
+	}, &issue)
+
+	return issue.Number, issue.HTMLURL, err
+}
+
+// SetClosed - closes or reopens issue
+func (c *Client) SetClosed(ctx context.Context, owner string, repo string, number int64, closed bool) error {
+	state := "open"
+	if closed {
+		state = "closed"
+	}
+
+	return c.do(ctx, "PATCH", repoPath(owner, repo, fmt.Sprintf("/issues/%d", number)), map[string]string{
+		"state": state,
+	}, nil)
+}
+
+// AddLabels - adds labels, Gitea does not create missing labels
+func (c *Client) AddLabels(ctx context.Context, owner string, repo string, number int64, labels []string) error {
+	ids, err := c.labelIDs(ctx, owner, repo)
+	if err != nil {
+		return fmt.Errorf("unable to look up labels: %w", err)
+	}
+
+	var add []int64
+	for _, name := range labels {
+		id, ok := ids[strings.ToLower(name)]
+		if !ok {
+			return fmt.Errorf("label %s does not exist in %s/%s", name, owner, repo)
+		}
+		add = append(add, id)
+	}
+
+	return c.do(ctx, "POST", repoPath(owner, repo, fmt.Sprintf("/issues/%d/labels", number)), map[string][]int64{
+		"labels": add,
+	}, nil)
+}
+
+// AddAssignees - assigns users, Gitea replaces assignees on edit, so current ones are loaded first
+func (c *Client) AddAssignees(ctx context.Context, owner string, repo string, number int64, logins []string) error {
+	path := repoPath(owner, repo, fmt.Sprintf("/issues/%d", number))
+
+	var issue struct {
+		Assignees []struct {
+			Login string `json:"login"`
+		} `json:"assignees"`
+	}
+	err := c.do(ctx, "GET", path, nil, &issue)
+	if err != nil {
+		return err
+	}
+
+	assignees := logins
+	for _, assignee := range issue.Assignees {
+		assignees = append(assignees, assignee.Login)
+	}
+
+	return c.do(ctx, "PATCH", path, map[string][]string{
+		"assignees": assignees,
+	}, nil)
+}
+
+// CreateComment - comments issue or pull request
+func (c *Client) CreateComment(ctx context.Context, owner string, repo string, number int64, body string) (int64, error) {
+	var comment struct {
+		ID int64 `json:"id"`
+	}
+	err := c.do(ctx, "POST", repoPath(owner, repo, fmt.Sprintf("/issues/%d/comments", number)), map[string]string{
+		"body": body,
+	}, &comment)
+
+	return comment.ID, err
+}
+
+// EditComment - edits comment
+func (c *Client) EditComment(ctx context.Context, owner string, repo string, commentID int64, body string) error {
+	return c.do(ctx, "PATCH", repoPath(owner, repo, fmt.Sprintf("/issues/comments/%d", commentID)), map[string]string{
+		"body": body,
+	}, nil)
+}
+
+// DeleteComment - deletes comment
+func (c *Client) DeleteComment(ctx context.Context, owner string, repo string, commentID int64) error {
+	return c.do(ctx, "DELETE", repoPath(owner, repo, fmt.Sprintf("/issues/comments/%d", commentID)), nil, nil)
+}
+
+// labelIDs - returns IDs of repo labels by lowercase name
+func (c *Client) labelIDs(ctx context.Context, owner string, repo string) (map[string]int64, error) {
+	ids := make(map[string]int64)
+	for page := 1; ; page++ {
+		var labels []struct {
+			ID   int64  `json:"id"`
+			Name string `json:"name"`
+		}
+		err := c.do(ctx, "GET", repoPath(owner, repo, fmt.Sprintf("/labels?page=%d&limit=%d", page, labelsPageSize)), nil, &labels)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, label := range labels {
+			ids[strings.ToLower(label.Name)] = label.ID
+		}
+		if len(labels) < labelsPageSize {
+			return ids, nil
+		}
+	}
+}
+
+// do - calls API method with JSON body, which may be nil, and parses response to result, which may be nil
+func (c *Client) do(ctx context.Context, method string, path string, body interface{}, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(buf)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+"/api/v1"+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "token "+c.token)
+
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	buf, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var message struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(buf, &message)
+		return &Error{Method: method, Path: path, StatusCode: resp.StatusCode, Message: message.Message}
+	}
+	if result == nil {
+		return nil
+	}
+
+	return json.Unmarshal(buf, result)
+}
+
+func repoPath(owner string, repo string, path string) string {
+	return "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo) + path
+}
@@ -0,0 +1,330 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andreyst/tracker-messenger-bridge/tracker"
+	gh "github.com/google/go-github/github"
+)
+
+const apiURL = "https://api.github.com"
+
+// Installation tokens live for an hour, they are refreshed this long before expiry
+const tokenRefreshMargin = 5 * time.Minute
+
+// App - Github App, acts on repositories with tokens of installations covering them,
+// so that comments are posted as the app bot, e.g. our-bridge[bot]
+// Installations are learned from installation webhooks, unknown repositories are looked up on first use
+type App struct {
+	id   int64
+	key  *rsa.PrivateKey
+	http *http.Client
+
+	mutex sync.Mutex
+	// Installation IDs by lowercase owner/repo
+	installations map[string]int64
+	tokens        map[int64]installationToken
+	// Installation used for requests not related to repository, 0 when not set
+	defaultInstallation int64
+}
+
+type installationToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// NewApp - creates app with ID and PEM encoded private key generated in app settings
+func NewApp(id int64, privateKey []byte) (*App, error) {
+	block, _ := pem.Decode(privateKey)
+	if block == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+
+	// Github generates PKCS#1 keys, PKCS#8 ones are accepted too
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		parsed, pkcs8Err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if pkcs8Err != nil {
+			return nil, fmt.Errorf("unable to parse private key: %w", err)
+		}
+		rsaKey, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("private key is not RSA key")
+		}
+		key = rsaKey
+	}
+
+	return &App{
+		id:            id,
+		key:           key,
+		http:          &http.Client{Timeout: time.Minute},
+		installations: make(map[string]int64),
+		tokens:        make(map[int64]installationToken),
+	}, nil
+}
+
+// NewAppClient - creates client acting as app installations
+func NewAppClient(app *App) *Client {
+	transport := &installationTransport{app: app}
+
+	return &Client{client: gh.NewClient(&http.Client{Transport: transport}), app: app}
+}
+
+// SetDefaultInstallation - sets installation used for requests not related to repository,
+// 0 means the only known installation
+func (a *App) SetDefaultInstallation(installationID int64) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.defaultInstallation = installationID
+}
+
+// Login - returns login of app bot user, e.g. our-bridge[bot]
+func (a *App) Login(ctx context.Context) (string, error) {
+	var app struct {
+		Slug string `json:"slug"`
+	}
+	err := a.request(ctx, "GET", "/app", &app)
+	if err != nil {
+		return "", permanent(err)
+	}
+
+	return app.Slug + "[bot]", nil
+}
+
+// AddRepos - remembers that repositories, owner/repo, are covered by installation
+func (a *App) AddRepos(installationID int64, repos []string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for _, repo := range repos {
+		a.installations[strings.ToLower(repo)] = installationID
+	}
+}
+
+// RemoveRepos - forgets repositories removed from installation
+func (a *App) RemoveRepos(repos []string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for _, repo := range repos {
+		delete(a.installations, strings.ToLower(repo))
+	}
+}
+
+// RemoveInstallation - forgets installation and its repositories after app is uninstalled
+func (a *App) RemoveInstallation(installationID int64) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for repo, id := range a.installations {
+		if id == installationID {
+			delete(a.installations, repo)
+		}
+	}
+	delete(a.tokens, installationID)
+}
+
+// Token - returns token of installation covering repository, tokens are cached until shortly before expiry
+func (a *App) Token(ctx context.Context, owner string, repo string) (string, error) {
+	installationID, err := a.installation(ctx, owner, repo)
+	if err != nil {
+		return "", err
+	}
+
+	return a.installationToken(ctx, installationID)
+}
+
+// DefaultToken - returns token of default installation, used for requests not related to repository
+func (a *App) DefaultToken(ctx context.Context) (string, error) {
+	a.mutex.Lock()
+	installationID := a.defaultInstallation
+	if installationID == 0 {
+		for _, id := range a.installations {
+			if installationID != 0 && id != installationID {
+				installationID = -1
+				break
+			}
+			installationID = id
+		}
+	}
+	a.mutex.Unlock()
+
+	switch installationID {
+	case 0:
+		return "", &tracker.PermanentError{Err: errors.New("github app has no known installations, set app_installation_id")}
+	case -1:
+		return "", &tracker.PermanentError{Err: errors.New("github app has several installations, set app_installation_id")}
+	}
+
+	return a.installationToken(ctx, installationID)
+}
+
+// installationToken - returns cached token of installation or issues a new one
+func (a *App) installationToken(ctx context.Context, installationID int64) (string, error) {
+	a.mutex.Lock()
+	cached, ok := a.tokens[installationID]
+	a.mutex.Unlock()
+	if ok && time.Until(cached.expiresAt) > tokenRefreshMargin {
+		return cached.token, nil
+	}
+
+	var issued struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	err := a.request(ctx, "POST", fmt.Sprintf("/app/installations/%d/access_tokens", installationID), &issued)
+	if err != nil {
+		return "", permanent(fmt.Errorf("unable to issue installation token: %w", err))
+	}
+
+	a.mutex.Lock()
+	a.tokens[installationID] = installationToken{token: issued.Token, expiresAt: issued.ExpiresAt}
+	a.mutex.Unlock()
+
+	return issued.Token, nil
+}
+
+// installation - returns ID of installation covering repository, looks it up if it is not known yet
+func (a *App) installation(ctx context.Context, owner string, repo string) (int64, error) {
+	fullName := strings.ToLower(owner + "/" + repo)
+
+	a.mutex.Lock()
+	installationID, ok := a.installations[fullName]
+	a.mutex.Unlock()
+	if ok {
+		return installationID, nil
+	}
+
+	var installation struct {
+		ID int64 `json:"id"`
+	}
+	err := a.request(ctx, "GET", "/repos/"+url.PathEscape(owner)+"/"+url.PathEscape(repo)+"/installation", &installation)
+	if err != nil {
+		return 0, permanent(fmt.Errorf("unable to find app installation for %s/%s: %w", owner, repo, err))
+	}
+	a.AddRepos(installation.ID, []string{fullName})
+
+	return installation.ID, nil
+}
+
+// request - calls API method authenticated as app and parses response to result
+func (a *App) request(ctx context.Context, method string, path string, result interface{}) error {
+	token, err := a.jwt(time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(method, apiURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := a.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Errors are reported as Github client ones, so that retries treat them the same way
+	err = gh.CheckResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// permanent - marks errors of requests refused by Github, e.g. for repository app is not installed on,
+// as permanent, server errors and rate limits are left to be retried
+func permanent(err error) error {
+	var errorResponse *gh.ErrorResponse
+	if errors.As(err, &errorResponse) && errorResponse.Response != nil &&
+		errorResponse.Response.StatusCode >= 400 && errorResponse.Response.StatusCode < 500 &&
+		errorResponse.Response.StatusCode != http.StatusTooManyRequests {
+		return &tracker.PermanentError{Err: err}
+	}
+
+	return err
+}
+
+// jwt - returns RS256 JWT authenticating app, valid for 9 minutes, Github allows at most 10
+func (a *App) jwt(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]int64{
+		// Issued a minute ago in case clocks drift
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": a.id,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("unable to sign app JWT: %w", err)
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// installationTransport - authenticates repository API requests with token of installation covering repository,
+// app API requests with app JWT and other requests with token of default installation
+type installationTransport struct {
+	app *App
+}
+
+func (t *installationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Paths look like /repos/{owner}/{repo}/...
+	parts := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/"), "/", 4)
+
+	var authorization string
+	switch {
+	case len(parts) >= 3 && parts[0] == "repos":
+		token, err := t.app.Token(req.Context(), parts[1], parts[2])
+		if err != nil {
+			return nil, err
+		}
+		authorization = "token " + token
+	case parts[0] == "app":
+		token, err := t.app.jwt(time.Now())
+		if err != nil {
+			return nil, &tracker.PermanentError{Err: err}
+		}
+		authorization = "Bearer " + token
+	default:
+		token, err := t.app.DefaultToken(req.Context())
+		if err != nil {
+			return nil, err
+		}
+		authorization = "token " + token
+	}
+
+	authorized := req.Clone(req.Context())
+	authorized.Header.Set("Authorization", authorization)
+
+	return http.DefaultTransport.RoundTrip(authorized)
+}
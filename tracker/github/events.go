@@ -0,0 +1,193 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"strings"
+
+	"github.com/andreyst/tracker-messenger-bridge/tracker"
+	"gopkg.in/go-playground/webhooks.v5/github"
+)
+
+// VerifySignature - checks HMAC of webhook body in X-Hub-Signature-256 header,
+// or in legacy SHA1 X-Hub-Signature header when the former is missing
+func VerifySignature(secret string, header http.Header, body []byte) bool {
+	signature, prefix, hashFunc := header.Get("X-Hub-Signature-256"), "sha256=", sha256.New
+	if signature == "" {
+		signature, prefix, hashFunc = header.Get("X-Hub-Signature"), "sha1=", func() hash.Hash { return sha1.New() }
+	}
+	if signature == "" {
+		return false
+	}
+
+	mac := hmac.New(hashFunc, []byte(secret))
+	mac.Write(body)
+	expected := prefix + hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// IssueEvent - converts issues webhook payload
+func IssueEvent(p github.IssuesPayload) tracker.IssueEvent {
+	e := tracker.IssueEvent{
+		Action: p.Action,
+		Repo: tracker.Repo{
+			Owner:    p.Repository.Owner.Login,
+			Name:     p.Repository.Name,
+			FullName: p.Repository.FullName,
+		},
+		Issue: tracker.Issue{
+			ID:        p.Issue.ID,
+			Number:    p.Issue.Number,
+			Title:     p.Issue.Title,
+			Body:      p.Issue.Body,
+			URL:       p.Issue.HTMLURL,
+			State:     p.Issue.State,
+			Author:    tracker.User{Login: p.Issue.User.Login, URL: p.Issue.User.HTMLURL},
+			UpdatedAt: p.Issue.UpdatedAt,
+		},
+		Sender: tracker.User{Login: p.Sender.Login, URL: p.Sender.HTMLURL},
+	}
+	for _, label := range p.Issue.Labels {
+		e.Issue.Labels = append(e.Issue.Labels, label.Name)
+	}
+	if p.Label != nil {
+		e.Label = p.Label.Name
+	}
+	if p.Assignee != nil {
+		e.Assignee = tracker.User{Login: p.Assignee.Login, URL: p.Assignee.HTMLURL}
+	}
+
+	return e
+}
+
+// CommentEvent - converts issue_comment webhook payload
+func CommentEvent(p github.IssueCommentPayload) tracker.CommentEvent {
+	e := tracker.CommentEvent{
+		Action: p.Action,
+		Repo: tracker.Repo{
+			Owner:    p.Repository.Owner.Login,
+			Name:     p.Repository.Name,
+			FullName: p.Repository.FullName,
+		},
+		Issue: tracker.Issue{
+			ID:          p.Issue.ID,
+			Number:      p.Issue.Number,
+			Title:       p.Issue.Title,
+			Body:        p.Issue.Body,
+			URL:         p.Issue.HTMLURL,
+			State:       p.Issue.State,
+			Author:      tracker.User{Login: p.Issue.User.Login, URL: p.Issue.User.HTMLURL},
+			PullRequest: p.Issue.PullRequest != nil,
+			UpdatedAt:   p.Issue.UpdatedAt,
+		},
+		Comment: tracker.Comment{
+			ID:     p.Comment.ID,
+			Body:   p.Comment.Body,
+			URL:    p.Comment.HTMLURL,
+			Author: tracker.User{Login: p.Comment.User.Login, URL: p.Comment.User.HTMLURL},
+		},
+		Sender: tracker.User{Login: p.Sender.Login, URL: p.Sender.HTMLURL},
+	}
+	for _, label := range p.Issue.Labels {
+		e.Issue.Labels = append(e.Issue.Labels, label.Name)
+	}
+
+	return e
+}
+
+// PullRequestEvent - converts pull_request webhook payload
+func PullRequestEvent(p github.PullRequestPayload) tracker.PullRequestEvent {
+	e := tracker.PullRequestEvent{
+		Action: p.Action,
+		Repo: tracker.Repo{
+			Owner:    p.Repository.Owner.Login,
+			Name:     p.Repository.Name,
+			FullName: p.Repository.FullName,
+		},
+		PullRequest: tracker.Issue{
+			ID:          p.PullRequest.ID,
+			Number:      p.PullRequest.Number,
+			Title:       p.PullRequest.Title,
+			Body:        p.PullRequest.Body,
+			URL:         p.PullRequest.HTMLURL,
+			State:       p.PullRequest.State,
+			Author:      tracker.User{Login: p.PullRequest.User.Login, URL: p.PullRequest.User.HTMLURL},
+			PullRequest: true,
+			UpdatedAt:   p.PullRequest.UpdatedAt,
+		},
+		Merged: p.PullRequest.Merged,
+		Sender: tracker.User{Login: p.Sender.Login, URL: p.Sender.HTMLURL},
+	}
+	for _, label := range p.PullRequest.Labels {
+		e.PullRequest.Labels = append(e.PullRequest.Labels, label.Name)
+	}
+
+	return e
+}
+
+// ReviewEvent - converts pull_request_review webhook payload
+func ReviewEvent(p github.PullRequestReviewPayload) tracker.ReviewEvent {
+	return tracker.ReviewEvent{
+		Action: p.Action,
+		Repo: tracker.Repo{
+			Owner:    p.Repository.Owner.Login,
+			Name:     p.Repository.Name,
+			FullName: p.Repository.FullName,
+		},
+		PullRequest: tracker.Issue{
+			ID:          p.PullRequest.ID,
+			Number:      p.PullRequest.Number,
+			Title:       p.PullRequest.Title,
+			Body:        p.PullRequest.Body,
+			URL:         p.PullRequest.HTMLURL,
+			State:       p.PullRequest.State,
+			Author:      tracker.User{Login: p.PullRequest.User.Login, URL: p.PullRequest.User.HTMLURL},
+			PullRequest: true,
+			UpdatedAt:   p.PullRequest.UpdatedAt,
+		},
+		Review: tracker.Review{
+			ID:     p.Review.ID,
+			State:  strings.ToLower(p.Review.State),
+			Body:   p.Review.Body,
+			URL:    p.Review.HTMLURL,
+			Author: tracker.User{Login: p.Review.User.Login, URL: p.Review.User.HTMLURL},
+		},
+		Sender: tracker.User{Login: p.Sender.Login, URL: p.Sender.HTMLURL},
+	}
+}
+
+// ReviewCommentEvent - converts pull_request_review_comment webhook payload
+func ReviewCommentEvent(p github.PullRequestReviewCommentPayload) tracker.ReviewCommentEvent {
+	return tracker.ReviewCommentEvent{
+		Action: p.Action,
+		Repo: tracker.Repo{
+			Owner:    p.Repository.Owner.Login,
+			Name:     p.Repository.Name,
+			FullName: p.Repository.FullName,
+		},
+		PullRequest: tracker.Issue{
+			ID:          p.PullRequest.ID,
+			Number:      p.PullRequest.Number,
+			Title:       p.PullRequest.Title,
+			Body:        p.PullRequest.Body,
+			URL:         p.PullRequest.HTMLURL,
+			State:       p.PullRequest.State,
+			Author:      tracker.User{Login: p.PullRequest.User.Login, URL: p.PullRequest.User.HTMLURL},
+			PullRequest: true,
+			UpdatedAt:   p.PullRequest.UpdatedAt,
+		},
+		Comment: tracker.Comment{
+			ID:     p.Comment.ID,
+			Body:   p.Comment.Body,
+			URL:    p.Comment.HTMLURL,
+			Author: tracker.User{Login: p.Comment.User.Login, URL: p.Comment.User.HTMLURL},
+			Path:   p.Comment.Path,
+		},
+		Sender: tracker.User{Login: p.Sender.Login, URL: p.Sender.HTMLURL},
+	}
+}
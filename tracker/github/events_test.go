@@ -0,0 +1,184 @@
+package github
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andreyst/tracker-messenger-bridge/tracker"
+	"gopkg.in/go-playground/webhooks.v5/github"
+)
+
+var (
+	repo  = tracker.Repo{Owner: "andreyst", Name: "tracker-messenger-bridge", FullName: "andreyst/tracker-messenger-bridge"}
+	alice = tracker.User{Login: "alice", URL: "https://github.com/alice"}
+	bob   = tracker.User{Login: "bob", URL: "https://github.com/bob"}
+	// Pull request as told by review and review comment payloads, which have no labels
+	pullRequest = tracker.Issue{
+		ID:          3001,
+		Number:      13,
+		Title:       "Fix crash on empty message",
+		Body:        "Fixes #12",
+		URL:         "https://github.com/andreyst/tracker-messenger-bridge/pull/13",
+		State:       "open",
+		Author:      bob,
+		PullRequest: true,
+		UpdatedAt:   time.Date(2021, 2, 3, 6, 0, 0, 0, time.UTC),
+	}
+)
+
+// readPayload - decodes webhook payload from testdata into Github payload type
+func readPayload(t *testing.T, name string, payload interface{}) {
+	t.Helper()
+
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("unable to read %s: %v", name, err)
+	}
+	if err := json.Unmarshal(data, payload); err != nil {
+		t.Fatalf("unable to parse %s: %v", name, err)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func(t *testing.T) interface{}
+		want  interface{}
+	}{
+		{
+			name: "issues",
+			parse: func(t *testing.T) interface{} {
+				var p github.IssuesPayload
+				readPayload(t, "issues.json", &p)
+				return IssueEvent(p)
+			},
+			want: tracker.IssueEvent{
+				Action: tracker.ActionLabeled,
+				Repo:   repo,
+				Issue: tracker.Issue{
+					ID:        1001,
+					Number:    12,
+					Title:     "Crash on empty message",
+					Body:      "Steps to reproduce",
+					URL:       "https://github.com/andreyst/tracker-messenger-bridge/issues/12",
+					State:     "open",
+					Author:    alice,
+					Labels:    []string{"bug"},
+					UpdatedAt: time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC),
+				},
+				Sender: bob,
+				Label:  "bug",
+			},
+		},
+		{
+			name: "issue_comment on pull request",
+			parse: func(t *testing.T) interface{} {
+				var p github.IssueCommentPayload
+				readPayload(t, "issue_comment.json", &p)
+				return CommentEvent(p)
+			},
+			want: tracker.CommentEvent{
+				Action: tracker.ActionCreated,
+				Repo:   repo,
+				Issue: tracker.Issue{
+					ID:          1002,
+					Number:      13,
+					Title:       "Fix crash on empty message",
+					Body:        "Fixes #12",
+					URL:         "https://github.com/andreyst/tracker-messenger-bridge/pull/13",
+					State:       "open",
+					Author:      bob,
+					PullRequest: true,
+					UpdatedAt:   time.Date(2021, 2, 3, 5, 0, 0, 0, time.UTC),
+				},
+				Comment: tracker.Comment{
+					ID:     2001,
+					Body:   "Looks good",
+					URL:    "https://github.com/andreyst/tracker-messenger-bridge/pull/13#issuecomment-2001",
+					Author: alice,
+				},
+				Sender: alice,
+			},
+		},
+		{
+			name: "merged pull_request",
+			parse: func(t *testing.T) interface{} {
+				var p github.PullRequestPayload
+				readPayload(t, "pull_request.json", &p)
+				return PullRequestEvent(p)
+			},
+			want: tracker.PullRequestEvent{
+				Action: tracker.ActionClosed,
+				Repo:   repo,
+				PullRequest: tracker.Issue{
+					ID:          3001,
+					Number:      13,
+					Title:       "Fix crash on empty message",
+					Body:        "Fixes #12",
+					URL:         "https://github.com/andreyst/tracker-messenger-bridge/pull/13",
+					State:       "closed",
+					Author:      bob,
+					Labels:      []string{"bug"},
+					PullRequest: true,
+					UpdatedAt:   time.Date(2021, 2, 4, 10, 0, 0, 0, time.UTC),
+				},
+				Merged: true,
+				Sender: tracker.User{Login: "andreyst", URL: "https://github.com/andreyst"},
+			},
+		},
+		{
+			name: "pull_request_review",
+			parse: func(t *testing.T) interface{} {
+				var p github.PullRequestReviewPayload
+				readPayload(t, "pull_request_review.json", &p)
+				return ReviewEvent(p)
+			},
+			want: tracker.ReviewEvent{
+				Action:      tracker.ActionSubmitted,
+				Repo:        repo,
+				PullRequest: pullRequest,
+				Review: tracker.Review{
+					ID:     4001,
+					State:  tracker.ReviewChangesRequested,
+					Body:   "Please add a test",
+					URL:    "https://github.com/andreyst/tracker-messenger-bridge/pull/13#pullrequestreview-4001",
+					Author: alice,
+				},
+				Sender: alice,
+			},
+		},
+		{
+			name: "pull_request_review_comment",
+			parse: func(t *testing.T) interface{} {
+				var p github.PullRequestReviewCommentPayload
+				readPayload(t, "pull_request_review_comment.json", &p)
+				return ReviewCommentEvent(p)
+			},
+			want: tracker.ReviewCommentEvent{
+				Action:      tracker.ActionCreated,
+				Repo:        repo,
+				PullRequest: pullRequest,
+				Comment: tracker.Comment{
+					ID:     5001,
+					Body:   "Nit: typo",
+					URL:    "https://github.com/andreyst/tracker-messenger-bridge/pull/13#discussion_r5001",
+					Author: alice,
+					Path:   "markdown/render.go",
+				},
+				Sender: alice,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.parse(t); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
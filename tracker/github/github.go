@@ -0,0 +1,158 @@
+package github
+
+import (
+	"context"
+
+	"github.com/andreyst/tracker-messenger-bridge/tracker"
+	gh "github.com/google/go-github/github"
+	"golang.org/x/oauth2"
+)
+
+// Name - tracker name
+const Name = "github"
+
+// Client - Github implementation of tracker.Tracker and tracker.ReviewComments
+type Client struct {
+	client *gh.Client
+	// Github App client acts as, nil when authenticated with token
+	app *App
+}
+
+// New - creates client authenticated with token
+func New(token string) *Client {
+	ts := oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: token},
+	)
+	tc := oauth2.NewClient(context.Background(), ts)
+
+	return &Client{client: gh.NewClient(tc)}
+}
+
+// App - returns Github App client acts as, nil for clients authenticated with token
+func (c *Client) App() *App {
+	return c.app
+}
+
+// Name - returns tracker name
+func (c *Client) Name() string {
+	return Name
+}
+
+// DisplayName - returns tracker name shown to users
+func (c *Client) DisplayName() string {
+	return "Github"
+}
+
+// As - returns client authenticated with token
+func (c *Client) As(token string) tracker.Tracker {
+	return New(token)
+}
+
+// CurrentUser - returns login of token owner, or of bot user for Github App, e.g. our-bridge[bot]
+func (c *Client) CurrentUser(ctx context.Context) (string, error) {
+	if c.app != nil {
+		return c.app.Login(ctx)
+	}
+
+	user, _, err := c.client.Users.Get(ctx, "")
+	if err != nil {
+		return "", err
+	}
+
+	return user.GetLogin(), nil
+}
+
+// CreateIssue - creates issue
+func (c *Client) CreateIssue(ctx context.Context, owner string, repo string, title string, body string) (int64, string, error) {
+	issue, _, err := c.client.Issues.Create(ctx, owner, repo, &gh.IssueRequest{
+		Title: &title,
+		Body:  &body,
+	})
+	if err != nil {
+		return 0, "", err
+	}
+
+	return int64(issue.GetNumber()), issue.GetHTMLURL(), nil
+}
+
+// SetClosed - closes or reopens issue
+func (c *Client) SetClosed(ctx context.Context, owner string, repo string, number int64, closed bool) error {
+	state := "open"
+	if closed {
+		state = "closed"
+	}
+
+	_, _, err := c.client.Issues.Edit(ctx, owner, repo, int(number), &gh.IssueRequest{
+		State: &state,
+	})
+
+	return err
+}
+
+// AddLabels - adds labels, missing labels are created by Github
+func (c *Client) AddLabels(ctx context.Context, owner string, repo string, number int64, labels []string) error {
+	_, _, err := c.client.Issues.AddLabelsToIssue(ctx, owner, repo, int(number), labels)
+
+	return err
+}
+
+// AddAssignees - assigns users
+func (c *Client) AddAssignees(ctx context.Context, owner string, repo string, number int64, logins []string) error {
+	_, _, err := c.client.Issues.AddAssignees(ctx, owner, repo, int(number), logins)
+
+	return err
+}
+
+// CreateComment - comments issue or pull request
+func (c *Client) CreateComment(ctx context.Context, owner string, repo string, number int64, body string) (int64, error) {
+	comment, _, err := c.client.Issues.CreateComment(ctx, owner, repo, int(number), &gh.IssueComment{
+		Body: &body,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return comment.GetID(), nil
+}
+
+// EditComment - edits issue comment
+func (c *Client) EditComment(ctx context.Context, owner string, repo string, commentID int64, body string) error {
+	_, _, err := c.client.Issues.EditComment(ctx, owner, repo, commentID, &gh.IssueComment{
+		Body: &body,
+	})
+
+	return err
+}
+
+// DeleteComment - deletes issue comment
+func (c *Client) DeleteComment(ctx context.Context, owner string, repo string, commentID int64) error {
+	_, err := c.client.Issues.DeleteComment(ctx, owner, repo, commentID)
+
+	return err
+}
+
+// ReplyToReviewComment - replies in thread of pull request review comment
+func (c *Client) ReplyToReviewComment(ctx context.Context, owner string, repo string, number int64, commentID int64, body string) (int64, error) {
+	comment, _, err := c.client.PullRequests.CreateCommentInReplyTo(ctx, owner, repo, int(number), body, commentID)
+	if err != nil {
+		return 0, err
+	}
+
+	return comment.GetID(), nil
+}
+
+// EditReviewComment - edits pull request review comment
+func (c *Client) EditReviewComment(ctx context.Context, owner string, repo string, commentID int64, body string) error {
+	_, _, err := c.client.PullRequests.EditComment(ctx, owner, repo, commentID, &gh.PullRequestComment{
+		Body: &body,
+	})
+
+	return err
+}
+
+// DeleteReviewComment - deletes pull request review comment
+func (c *Client) DeleteReviewComment(ctx context.Context, owner string, repo string, commentID int64) error {
+	_, err := c.client.PullRequests.DeleteComment(ctx, owner, repo, commentID)
+
+	return err
+}
@@ -0,0 +1,59 @@
+package tracker
+
+import (
+	"context"
+)
+
+// Tracker - issue tracker bridged to messenger
+// Issues are identified by owner, repo and number, comments by ID unique within tracker
+type Tracker interface {
+	// Name - tracker name, e.g. "github"
+	Name() string
+	// DisplayName - tracker name shown to users, e.g. "Github"
+	DisplayName() string
+	// As - returns tracker acting as user with token, used for users who linked their accounts
+	As(token string) Tracker
+	// CurrentUser - returns login of user tracker acts as
+	CurrentUser(ctx context.Context) (string, error)
+
+	// CreateIssue - creates issue and returns its number and URL
+	CreateIssue(ctx context.Context, owner string, repo string, title string, body string) (int64, string, error)
+	// SetClosed - closes or reopens issue
+	SetClosed(ctx context.Context, owner string, repo string, number int64, closed bool) error
+	// AddLabels - adds labels to issue by name
+	AddLabels(ctx context.Context, owner string, repo string, number int64, labels []string) error
+	// AddAssignees - assigns users to issue by login, keeping current assignees
+	AddAssignees(ctx context.Context, owner string, repo string, number int64, logins []string) error
+
+	// CreateComment - comments issue and returns comment ID
+	CreateComment(ctx context.Context, owner string, repo string, number int64, body string) (int64, error)
+	// EditComment - replaces comment body
+	EditComment(ctx context.Context, owner string, repo string, commentID int64, body string) error
+	// DeleteComment - deletes comment
+	DeleteComment(ctx context.Context, owner string, repo string, commentID int64) error
+}
+
+// PermanentError - error of request tracker client can not make, retrying it does not help,
+// e.g. request to repository app is not installed on
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap - returns underlying error
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// ReviewComments - tracker with comments on pull request diff lines, e.g. Github
+type ReviewComments interface {
+	// ReplyToReviewComment - replies in thread of review comment and returns reply ID
+	ReplyToReviewComment(ctx context.Context, owner string, repo string, number int64, commentID int64, body string) (int64, error)
+	// EditReviewComment - replaces review comment body
+	EditReviewComment(ctx context.Context, owner string, repo string, commentID int64, body string) error
+	// DeleteReviewComment - deletes review comment
+	DeleteReviewComment(ctx context.Context, owner string, repo string, commentID int64) error
+}
@@ -0,0 +1,54 @@
+package webhooks
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	bot "github.com/andreyst/tracker-messenger-bridge/bot"
+	"github.com/andreyst/tracker-messenger-bridge/tracker"
+	"github.com/andreyst/tracker-messenger-bridge/tracker/gitea"
+)
+
+// GiteaWebhook - handle for Gitea webhook, issue, pull request and comment events are converted to tracker events
+type GiteaWebhook struct{}
+
+func init() {
+	bot.RegisterEventKind(tracker.KindIssue, tracker.IssueEvent{})
+	bot.RegisterEventKind(tracker.KindComment, tracker.CommentEvent{})
+	bot.RegisterEventKind(tracker.KindPullRequest, tracker.PullRequestEvent{})
+	bot.RegisterEventKind(tracker.KindReview, tracker.ReviewEvent{})
+}
+
+// Authenticate - checks signature of Gitea event
+func (GiteaWebhook) Authenticate(b *bot.Bot, header http.Header, body []byte) bool {
+	return gitea.VerifySignature(b.Config.Gitea.WebhookSecret, header, body)
+}
+
+// SourceID - uses delivery ID to skip events Gitea delivers again
+func (GiteaWebhook) SourceID(header http.Header, body []byte) string {
+	return header.Get("X-Gitea-Delivery")
+}
+
+// Handle - handle Gitea webhook
+func (GiteaWebhook) Handle(b *bot.Bot, r *http.Request) error {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	if !gitea.VerifySignature(b.Config.Gitea.WebhookSecret, r.Header, body) {
+		return bot.Unprocessed("gitea event with invalid signature")
+	}
+
+	event, ok, err := gitea.ParseEvent(r.Header.Get("X-Gitea-Event"), body)
+	if err != nil {
+		return bot.Unprocessed("gitea hook parse: %v", err)
+	}
+	if !ok {
+		fmt.Printf("Skipping gitea event %s\n", r.Header.Get("X-Gitea-Event"))
+		return nil
+	}
+
+	return b.Emit(r.Context(), gitea.Name, r.Header.Get("X-Gitea-Delivery"), event)
+}
@@ -3,29 +3,72 @@ package webhooks
 import (
 	"fmt"
 	"net/http"
-	"os"
 
 	bot "github.com/andreyst/tracker-messenger-bridge/bot"
+	"github.com/andreyst/tracker-messenger-bridge/tracker"
+	trackergithub "github.com/andreyst/tracker-messenger-bridge/tracker/github"
 	"gopkg.in/go-playground/webhooks.v5/github"
 )
 
 // GithubWebhook - handle for github webhook
+// Issue, pull request and comment events are converted to tracker events, installation ones are handled as Github payloads
 type GithubWebhook struct{}
 
+func init() {
+	bot.RegisterEventKind(tracker.KindIssue, tracker.IssueEvent{})
+	bot.RegisterEventKind(tracker.KindComment, tracker.CommentEvent{})
+	bot.RegisterEventKind(tracker.KindPullRequest, tracker.PullRequestEvent{})
+	bot.RegisterEventKind(tracker.KindReview, tracker.ReviewEvent{})
+	bot.RegisterEventKind(tracker.KindReviewComment, tracker.ReviewCommentEvent{})
+	bot.RegisterEventKind("github.installation", github.InstallationPayload{})
+	bot.RegisterEventKind("github.installation_repositories", github.InstallationRepositoriesPayload{})
+}
+
+// Authenticate - checks signature of Github event
+func (GithubWebhook) Authenticate(b *bot.Bot, header http.Header, body []byte) bool {
+	return trackergithub.VerifySignature(b.Config.Github.WebhookSecret, header, body)
+}
+
+// SourceID - uses delivery GUID to skip events Github redelivers on timeouts or by hand
+func (GithubWebhook) SourceID(header http.Header, body []byte) string {
+	return header.Get("X-GitHub-Delivery")
+}
+
 // Handle - handle github webhook
-func (GithubWebhook) Handle(b *bot.Bot, r *http.Request) {
+func (GithubWebhook) Handle(b *bot.Bot, r *http.Request) error {
 	// TODO: refactor to custom handling code without request
-	hook, _ := github.New(github.Options.Secret(os.Getenv("GITHUB_WEBHOOK_SECRET")))
-	payload, err := hook.Parse(r, github.IssuesEvent, github.IssueCommentEvent)
-	fmt.Printf("===NEW PAYLOAD:\n%v\n", payload)
+	hook, _ := github.New(github.Options.Secret(b.Config.Github.WebhookSecret))
+	payload, err := hook.Parse(r,
+		github.IssuesEvent,
+		github.IssueCommentEvent,
+		github.PullRequestEvent,
+		github.PullRequestReviewEvent,
+		github.PullRequestReviewCommentEvent,
+		github.InstallationEvent,
+		github.InstallationRepositoriesEvent,
+	)
 	if err != nil {
 		if err == github.ErrEventNotFound {
 			fmt.Printf("Skipping github event\n")
 			// ok event wasn't one of the ones asked to be parsed
-		} else {
-			fmt.Printf("Github hook parse: %v\n", err)
+			return nil
 		}
+		return bot.Unprocessed("github hook parse: %v", err)
+	}
+
+	id := r.Header.Get("X-GitHub-Delivery")
+	switch p := payload.(type) {
+	case github.IssuesPayload:
+		return b.Emit(r.Context(), trackergithub.Name, id, trackergithub.IssueEvent(p))
+	case github.IssueCommentPayload:
+		return b.Emit(r.Context(), trackergithub.Name, id, trackergithub.CommentEvent(p))
+	case github.PullRequestPayload:
+		return b.Emit(r.Context(), trackergithub.Name, id, trackergithub.PullRequestEvent(p))
+	case github.PullRequestReviewPayload:
+		return b.Emit(r.Context(), trackergithub.Name, id, trackergithub.ReviewEvent(p))
+	case github.PullRequestReviewCommentPayload:
+		return b.Emit(r.Context(), trackergithub.Name, id, trackergithub.ReviewCommentEvent(p))
 	}
 
-	b.EventsChan <- payload
+	return b.Emit(r.Context(), trackergithub.Name, id, payload)
 }
@@ -0,0 +1,70 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	bot "github.com/andreyst/tracker-messenger-bridge/bot"
+	"github.com/andreyst/tracker-messenger-bridge/messenger"
+	"github.com/andreyst/tracker-messenger-bridge/messenger/slack"
+)
+
+// SlackWebhook - handle for Slack Events API requests, Slack can not be polled for updates
+type SlackWebhook struct{}
+
+func init() {
+	bot.RegisterEventKind(messenger.KindUpdate, messenger.Update{})
+}
+
+// Authenticate - checks that request is signed with signing secret recently, so that it is not a replay
+func (SlackWebhook) Authenticate(b *bot.Bot, header http.Header, body []byte) bool {
+	return slack.VerifySignature(b.Config.Slack.SigningSecret, header, body) && slack.IsFresh(header, time.Now())
+}
+
+// Respond - answers URL verification challenge Slack sends when request URL of app is set
+func (SlackWebhook) Respond(b *bot.Bot, header http.Header, body []byte) ([]byte, bool) {
+	challenge, ok := slack.Challenge(body)
+	if !ok {
+		return nil, false
+	}
+
+	return []byte(challenge), true
+}
+
+// SourceID - uses event ID to skip events Slack delivers again when they are not acknowledged in time
+func (SlackWebhook) SourceID(header http.Header, body []byte) string {
+	return slack.EventID(body)
+}
+
+// Handle - handle Slack event
+// Freshness of signature is checked in Authenticate only, as queued events can be handled long after delivery
+func (SlackWebhook) Handle(b *bot.Bot, r *http.Request) error {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	if !slack.VerifySignature(b.Config.Slack.SigningSecret, r.Header, body) {
+		return bot.Unprocessed("slack event with invalid signature")
+	}
+
+	update, ok, err := b.Messenger.Decode(body)
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+		return bot.Unprocessed("slack event parse: %v", err)
+	}
+	if err != nil {
+		// User names are looked up while decoding, lookups failed with transient errors are retried with backoff
+		return b.RetryTransient(r.Context(), fmt.Errorf("unable to decode slack event: %w", err))
+	}
+	if !ok {
+		return nil
+	}
+
+	return b.Emit(r.Context(), b.Messenger.Name(), update.ID, update)
+}
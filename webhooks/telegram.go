@@ -0,0 +1,81 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	bot "github.com/andreyst/tracker-messenger-bridge/bot"
+	"github.com/andreyst/tracker-messenger-bridge/messenger"
+	"github.com/andreyst/tracker-messenger-bridge/messenger/telegram"
+)
+
+const telegramSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// TelegramWebhook - handle for Telegram webhook, alternative to pollers.MessengerPoller
+type TelegramWebhook struct{}
+
+func init() {
+	bot.RegisterEventKind(messenger.KindUpdate, messenger.Update{})
+}
+
+// Register - points Telegram webhook to configured URL
+func (TelegramWebhook) Register(ctx context.Context, b *bot.Bot) error {
+	client, ok := b.Messenger.(*telegram.Client)
+	if !ok {
+		return fmt.Errorf("expected telegram messenger, got %s", b.Messenger.Name())
+	}
+
+	err := client.SetWebhook(b.Config.Telegram.WebhookURL, b.Config.Telegram.WebhookSecret)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Registered Telegram webhook %s\n", b.Config.Telegram.WebhookURL)
+
+	return nil
+}
+
+// Authenticate - checks secret token Telegram was given when webhook was registered
+func (TelegramWebhook) Authenticate(b *bot.Bot, header http.Header, body []byte) bool {
+	secret := header.Get(telegramSecretHeader)
+	return subtle.ConstantTimeCompare([]byte(secret), []byte(b.Config.Telegram.WebhookSecret)) == 1
+}
+
+// SourceID - uses update ID to skip repeated deliveries of the same update
+func (TelegramWebhook) SourceID(header http.Header, body []byte) string {
+	var update struct {
+		UpdateID int `json:"update_id"`
+	}
+	if err := json.Unmarshal(body, &update); err != nil || update.UpdateID == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(update.UpdateID)
+}
+
+// Handle - handle Telegram webhook
+func (TelegramWebhook) Handle(b *bot.Bot, r *http.Request) error {
+	secret := r.Header.Get(telegramSecretHeader)
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(b.Config.Telegram.WebhookSecret)) != 1 {
+		return bot.Unprocessed("telegram update with invalid secret token")
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	update, ok, err := b.Messenger.Decode(body)
+	if err != nil {
+		return bot.Unprocessed("telegram hook parse: %v", err)
+	}
+	if !ok {
+		return nil
+	}
+
+	return b.Emit(r.Context(), b.Messenger.Name(), update.ID, update)
+}